@@ -0,0 +1,227 @@
+// Command colinspect prints a summary of a persisted column, or dumps a range of its rows
+// The format of the file is detected from its type tag, so RLELIST (rlelist.RleList), RLEARRAY (block.Block) and
+// RLECOLUMN files can all be inspected. The summary shows the format, the row and run counts, the compression ratio
+// and a histogram of the types of the values stored. With -dump the rows are written as text, CSV or JSON instead.
+//
+//	colinspect column.dat
+//	colinspect -dump -from 1000 -count 50 -format csv column.dat
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/columnfile"
+)
+
+// options for dumping rows
+type dumpOptions struct {
+	from   uint   // first row to dump
+	count  uint   // number of rows to dump, 0 for every row from the first
+	format string // one of text, csv or json
+}
+
+func main() {
+	dumpRows := flag.Bool("dump", false, "dump rows instead of printing the summary")
+	from := flag.Uint("from", 0, "first row to dump")
+	count := flag.Uint("count", 0, "number of rows to dump, 0 for every row from the first")
+	format := flag.String("format", "text", "dump format, one of text, csv or json")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: colinspect [-dump [-from row] [-count rows] [-format text|csv|json]] file")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	if *dumpRows {
+		err = dumpFile(os.Stdout, flag.Arg(0), dumpOptions{from: *from, count: *count, format: *format})
+	} else {
+		err = inspect(os.Stdout, flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "colinspect:", err)
+		os.Exit(1)
+	}
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	SUMMARY
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// the number of rows and runs holding values of a type
+type typeCount struct {
+	name string
+	rows uint
+	runs uint
+}
+
+// writes a summary of the column in the file at path
+func inspect(w io.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	c, format, err := columnfile.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	rows := c.RowCount()
+	runs := c.BlockCount()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "file:\t%s\n", path)
+	fmt.Fprintf(tw, "size:\t%d bytes\n", info.Size())
+	fmt.Fprintf(tw, "format:\t%s\n", format)
+	fmt.Fprintf(tw, "rows:\t%d\n", rows)
+	fmt.Fprintf(tw, "runs:\t%d\n", runs)
+	if runs > 0 {
+		fmt.Fprintf(tw, "compression ratio:\t%.2f rows per run\n", float64(rows)/float64(runs))
+		fmt.Fprintf(tw, "bytes per row:\t%.2f\n", float64(info.Size())/float64(rows))
+	}
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "value types:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	for _, tc := range typeHistogram(c) {
+		fmt.Fprintf(tw, "  %s\t%d rows\t%.1f%%\t%d runs\t\n", tc.name, tc.rows, 100*float64(tc.rows)/float64(rows), tc.runs)
+	}
+	return tw.Flush()
+}
+
+// counts the rows and runs holding each type of value, most rows first
+func typeHistogram(c column.Column) []typeCount {
+	counts := map[string]*typeCount{}
+	c.IterateRuns(func(index uint, length uint, value interface{}) {
+		name := fmt.Sprintf("%T", value)
+		tc, ok := counts[name]
+		if !ok {
+			tc = &typeCount{name: name}
+			counts[name] = tc
+		}
+		tc.rows += length
+		tc.runs++
+	})
+
+	result := make([]typeCount, 0, len(counts))
+	for _, tc := range counts {
+		result = append(result, *tc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].rows != result[j].rows {
+			return result[i].rows > result[j].rows
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	DUMP
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// writes the rows of the column in the file at path selected by the options
+func dumpFile(w io.Writer, path string, opts dumpOptions) error {
+	c, _, err := columnfile.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return dump(w, c, opts)
+}
+
+// writes the rows of the column selected by the options
+func dump(w io.Writer, c column.Column, opts dumpOptions) error {
+	switch opts.format {
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "row\tvalue\ttype")
+		eachRow(c, opts, func(row uint, value interface{}) {
+			fmt.Fprintf(tw, "%d\t%v\t%T\n", row, value, value)
+		})
+		return tw.Flush()
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"row", "value", "type"})
+		eachRow(c, opts, func(row uint, value interface{}) {
+			text := ""
+			if value != nil {
+				text = fmt.Sprint(value)
+			}
+			cw.Write([]string{strconv.FormatUint(uint64(row), 10), text, fmt.Sprintf("%T", value)})
+		})
+		cw.Flush()
+		return cw.Error()
+
+	case "json":
+		// rows are written as a JSON array as they are visited, so large ranges are never held in memory
+		var err error
+		first := true
+		_, err = io.WriteString(w, "[")
+		eachRow(c, opts, func(row uint, value interface{}) {
+			if err != nil {
+				return
+			}
+			var data []byte
+			data, err = json.Marshal(struct {
+				Row   uint        `json:"row"`
+				Value interface{} `json:"value"`
+				Type  string      `json:"type"`
+			}{row, value, fmt.Sprintf("%T", value)})
+			if err != nil {
+				return
+			}
+			if !first {
+				_, err = io.WriteString(w, ",")
+			}
+			first = false
+			if err == nil {
+				_, err = fmt.Fprintf(w, "\n  %s", data)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n]\n")
+		return err
+	}
+	return fmt.Errorf("unknown dump format %q", opts.format)
+}
+
+// calls f for each row selected by the options, skipping whole runs before the first row
+func eachRow(c column.Column, opts dumpOptions, f column.IteratorFn) {
+	end := c.RowCount()
+	if opts.count > 0 && opts.count < end-min(opts.from, end) {
+		end = opts.from + opts.count
+	}
+
+	for run := range c.Runs() {
+		if run.Index >= end {
+			return
+		}
+		if run.Index+run.Length <= opts.from {
+			continue
+		}
+		for row := max(run.Index, opts.from); row < run.Index+run.Length && row < end; row++ {
+			f(row, run.Value)
+		}
+	}
+}
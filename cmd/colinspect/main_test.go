@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/rlelist"
+)
+
+// writes an RleList holding 100 "a" rows, then 1, nil and 2.5, returning the path of the file
+func columnFile(t *testing.T) string {
+	list := rlelist.New()
+	list.AppendRun("a", 100)
+	list.AppendMany(1, nil, 2.5)
+
+	path := filepath.Join(t.TempDir(), "column.dat")
+	f, err := os.Create(path)
+	assert.Nil(t, err, "Unexpected Error")
+	defer f.Close()
+	assert.Nil(t, list.Write(f), "Unexpected Write Error")
+	return path
+}
+
+func TestInspect(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := inspect(buf, columnFile(t))
+	assert.Nil(t, err, "Unexpected Error")
+
+	out := buf.String()
+	for _, expected := range []string{"format:             RLELIST", "rows:               103", "runs:               4",
+		"compression ratio:  25.75 rows per run", "string  100 rows", "<nil>    1 rows"} {
+		if !strings.Contains(out, expected) {
+			t.Error("Expected the summary to contain", expected, "got\n", out)
+		}
+	}
+	assert.Equal(t, strings.Index(out, "string") < strings.Index(out, "float64"), true, "Expected the most common type first")
+}
+
+func TestDumpText(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := dumpFile(buf, columnFile(t), dumpOptions{from: 99, count: 3, format: "text"})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, strings.Split(buf.String(), "\n"), []string{
+		"row  value  type",
+		"99   a      string",
+		"100  1      int",
+		"101  <nil>  <nil>",
+		"",
+	})
+}
+
+func TestDumpCSV(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := dumpFile(buf, columnFile(t), dumpOptions{from: 101, format: "csv"})
+	assert.Nil(t, err, "Unexpected Error")
+
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.Nil(t, err, "Unexpected CSV Error")
+	assert.Equal(t, records, [][]string{{"row", "value", "type"}, {"101", "", "<nil>"}, {"102", "2.5", "float64"}})
+}
+
+func TestDumpJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := dumpFile(buf, columnFile(t), dumpOptions{from: 0, count: 2, format: "json"})
+	assert.Nil(t, err, "Unexpected Error")
+
+	rows := []map[string]interface{}{}
+	err = json.Unmarshal(buf.Bytes(), &rows)
+	assert.Nil(t, err, "Unexpected JSON Error")
+	assert.Equal(t, rows, []map[string]interface{}{
+		{"row": 0.0, "value": "a", "type": "string"},
+		{"row": 1.0, "value": "a", "type": "string"},
+	})
+
+	buf.Reset()
+	err = dumpFile(buf, columnFile(t), dumpOptions{from: 500, format: "json"})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.String(), "[\n]\n", "Expected an empty array for rows beyond the end")
+}
+
+func TestDumpUnknownFormat(t *testing.T) {
+	err := dumpFile(new(bytes.Buffer), columnFile(t), dumpOptions{format: "xml"})
+	assert.NotNil(t, err, "Expected an error for an unknown format")
+}
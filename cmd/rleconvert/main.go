@@ -0,0 +1,85 @@
+// Command rleconvert converts persisted run length encoded columns between formats
+// The format of the input is detected from its type tag, so RLELIST (rlelist.RleList), RLEARRAY (block.Block) and
+// RLECOLUMN (the versioned column format) files can all be read. The output is written in the format named by -to.
+//
+//	rleconvert -to RLECOLUMN -o out.dat in.dat
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/columnfile"
+	"github.com/lummie/golib/rlelist"
+)
+
+func main() {
+	to := flag.String("to", column.MagicColumn, "output format, one of RLELIST, RLEARRAY or RLECOLUMN")
+	out := flag.String("o", "", "output file, standard output if not given")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: rleconvert [-to format] [-o output] input")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := convert(flag.Arg(0), *out, strings.ToUpper(*to))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rleconvert:", err)
+		os.Exit(1)
+	}
+}
+
+// reads the column in input and writes it to output in the format given
+func convert(input string, output string, to string) error {
+	var write func(w io.Writer, c column.Column) error
+	switch to {
+	case column.MagicRleList:
+		write = func(w io.Writer, c column.Column) error { return rlelist.FromBlock(c).Write(w) }
+	case column.MagicBlock:
+		write = func(w io.Writer, c column.Column) error { return block.FromRleList(c).Write(w) }
+	case column.MagicColumn:
+		write = column.Write
+	default:
+		return fmt.Errorf("unknown output format %q", to)
+	}
+
+	c, from, err := columnfile.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "read %d rows in %d runs from %s %s\n", c.RowCount(), c.BlockCount(), from, input)
+
+	if output == "" {
+		bw := bufio.NewWriter(os.Stdout)
+		err = write(bw, c)
+		if err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	err = write(bw, c)
+	if err == nil {
+		err = bw.Flush()
+	}
+	// the close error is returned as it may be the first to report the data was not written
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
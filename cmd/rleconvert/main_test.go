@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/rlelist"
+)
+
+func TestConvertThroughEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	list := rlelist.New()
+	list.AppendMany("a", "a", "b", 1, 2.5)
+
+	input := filepath.Join(dir, "in.dat")
+	f, err := os.Create(input)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Nil(t, list.Write(f), "Unexpected Write Error")
+	f.Close()
+
+	previous := input
+	for _, to := range []string{column.MagicBlock, column.MagicColumn, column.MagicRleList} {
+		output := filepath.Join(dir, to)
+		assert.Nil(t, convert(previous, output, to), "Unexpected Error converting to", to)
+		previous = output
+	}
+
+	expected, _ := os.ReadFile(input)
+	actual, _ := os.ReadFile(previous)
+	assert.Equal(t, actual, expected, "Expected converting back to RLELIST to reproduce the input")
+
+	err = convert(input, filepath.Join(dir, "out"), "CSV")
+	assert.NotNil(t, err, "Expected an error for an unknown output format")
+}
@@ -0,0 +1,181 @@
+package aggregate
+
+// Computes COUNT, SUM, MIN, MAX and AVG directly over the runs of a block.Block
+// Each run contributes its value multiplied by its length, so the rows of a run are never expanded
+
+import (
+	"math"
+
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/rowindex"
+	"github.com/lummie/golib/column/value"
+)
+
+// Stats holds the running aggregates of a set of rows
+// nil values are counted in Rows but are otherwise ignored, in the same way as SQL aggregates ignore NULL
+type Stats struct {
+	Rows  uint        // number of rows aggregated, including nil values
+	Count uint        // number of non nil values aggregated
+	Min   interface{} // smallest non nil value, nil if there are none, not valid if Value(Min) returns an error
+	Max   interface{} // largest non nil value, nil if there are none, not valid if Value(Max) returns an error
+
+	intSum     int64   // sum while every value has been an integer
+	floatSum   float64 // sum once a float, or an integer too large for intSum, has been seen
+	isFloat    bool    // true when the sum is held in floatSum
+	nonNumeric bool    // true if a non numeric value has been aggregated
+	err        error   // first error encountered comparing values
+}
+
+// aggregates every row of the Block
+// values that cannot be compared only affect Min and Max, so the error is reported by Value(Min) and Value(Max)
+func Column(b *block.Block) (*Stats, error) {
+	s := &Stats{}
+	b.IterateRuns(s.addRun)
+	return s, nil
+}
+
+// aggregates the rows of the Block included in the row set, reporting values that cannot be compared like Column
+// the ranges in the row set are expected not to overlap, rows outside of the Block are ignored
+func Rows(b *block.Block, rows *rowindex.RowIndex) (*Stats, error) {
+	s := &Stats{}
+	rows.Iterate(func(index uint, length uint) {
+		b.IterateRunsRange(index, length, s.addRun)
+	})
+	return s, nil
+}
+
+// returns the sum of the values as a float64
+func (s *Stats) Sum() (float64, error) {
+	if s.nonNumeric {
+		return 0, value.ErrNotNumeric
+	}
+	if s.isFloat {
+		return s.floatSum, nil
+	}
+	return float64(s.intSum), nil
+}
+
+// returns the sum of the values as an int64
+// returns ErrNotNumeric if any value was not an integer or the sum does not fit in an int64
+func (s *Stats) IntSum() (int64, error) {
+	if s.nonNumeric || s.isFloat {
+		return 0, value.ErrNotNumeric
+	}
+	return s.intSum, nil
+}
+
+// returns the mean of the values, or 0 if there are none
+func (s *Stats) Avg() (float64, error) {
+	sum, err := s.Sum()
+	if err != nil || s.Count == 0 {
+		return 0, err
+	}
+	return sum / float64(s.Count), nil
+}
+
+// adds a run of length rows all holding v
+func (s *Stats) addRun(index uint, length uint, v interface{}) {
+	s.Rows += length
+	if v == nil {
+		return
+	}
+	s.Count += length
+
+	// typed paths for the common kinds avoid the generic conversions in the value package
+	switch n := v.(type) {
+	case int:
+		s.addInt(int64(n), length)
+	case int64:
+		s.addInt(n, length)
+	case int32:
+		s.addInt(int64(n), length)
+	case uint:
+		s.addUint(uint64(n), length)
+	case uint64:
+		s.addUint(n, length)
+	case uint32:
+		s.addInt(int64(n), length)
+	case float64:
+		s.addFloat(n, length)
+	case float32:
+		s.addFloat(float64(n), length)
+	default:
+		if i, ok := value.Int64(v); ok {
+			s.addInt(i, length)
+		} else if f, ok := value.Float64(v); ok {
+			s.addFloat(f, length)
+		} else {
+			s.nonNumeric = true
+		}
+	}
+
+	s.addMinMax(v)
+}
+
+func (s *Stats) addInt(v int64, length uint) {
+	if s.isFloat {
+		s.floatSum += float64(v) * float64(length)
+		return
+	}
+
+	// move to the float sum if the length, the product or the sum overflows
+	overflow := uint64(length) > math.MaxInt64
+	var sum int64
+	if !overflow {
+		product := v * int64(length)
+		sum = s.intSum + product
+		overflow = (v != 0 && product/v != int64(length)) ||
+			(product > 0 && sum < s.intSum) || (product < 0 && sum > s.intSum)
+	}
+	if overflow {
+		s.isFloat = true
+		s.floatSum = float64(s.intSum) + float64(v)*float64(length)
+		return
+	}
+	s.intSum = sum
+}
+
+func (s *Stats) addUint(v uint64, length uint) {
+	if v > math.MaxInt64 {
+		s.addFloat(float64(v), length)
+		return
+	}
+	s.addInt(int64(v), length)
+}
+
+func (s *Stats) addFloat(v float64, length uint) {
+	if !s.isFloat {
+		s.isFloat = true
+		s.floatSum = float64(s.intSum)
+	}
+	s.floatSum += v * float64(length)
+}
+
+func (s *Stats) addMinMax(v interface{}) {
+	if s.err != nil {
+		return
+	}
+	if s.Min == nil {
+		s.Min = v
+		s.Max = v
+		return
+	}
+
+	c, err := value.Compare(v, s.Min)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if c < 0 {
+		s.Min = v
+	}
+
+	c, err = value.Compare(v, s.Max)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if c > 0 {
+		s.Max = v
+	}
+}
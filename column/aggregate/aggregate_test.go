@@ -0,0 +1,144 @@
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/rowindex"
+	"github.com/lummie/golib/column/value"
+)
+
+func TestColumnIntegers(t *testing.T) {
+	b := block.New(10)
+	for i := 0; i < 100; i++ {
+		b.Append(i / 10) // 10 runs of 10 rows, 0..9
+	}
+
+	s, err := Column(b)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Rows, uint(100))
+	assert.Equal(t, s.Count, uint(100))
+	assert.Equal(t, s.Min, 0)
+	assert.Equal(t, s.Max, 9)
+
+	sum, err := s.IntSum()
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, sum, int64(450))
+
+	avg, _ := s.Avg()
+	assert.Equal(t, avg, 4.5)
+}
+
+func TestColumnMixedNumericAndNil(t *testing.T) {
+	b := block.New(10)
+	b.Append(1)
+	b.Append(1)
+	b.Append(nil)
+	b.Append(2.5)
+	b.Append(uint8(3))
+
+	s, err := Column(b)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Rows, uint(5))
+	assert.Equal(t, s.Count, uint(4), "Expected nil values to be excluded from Count")
+
+	sum, _ := s.Sum()
+	assert.Equal(t, sum, 7.5)
+	_, err = s.IntSum()
+	assert.Equal(t, err, value.ErrNotNumeric, "Expected IntSum to fail once a float is seen")
+	assert.Equal(t, s.Min, 1)
+	assert.Equal(t, s.Max, uint8(3))
+}
+
+func TestColumnStrings(t *testing.T) {
+	b := block.New(10)
+	b.Append("pear")
+	b.Append("apple")
+	b.Append("zucchini")
+
+	s, err := Column(b)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Min, "apple")
+	assert.Equal(t, s.Max, "zucchini")
+	_, err = s.Sum()
+	assert.Equal(t, err, value.ErrNotNumeric)
+}
+
+func TestColumnNotComparable(t *testing.T) {
+	b := block.New(10)
+	b.Append("1")
+	b.Append(1)
+
+	// only Min and Max need the values to be compared
+	s, err := Column(b)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Count, uint(2))
+	_, err = s.Value(Min)
+	assert.Equal(t, err, value.ErrNotComparable)
+	_, err = s.Value(Max)
+	assert.Equal(t, err, value.ErrNotComparable)
+	count, err := s.Value(Count)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, count, uint(2))
+
+	rows := rowindex.New()
+	rows.Append(0, 2)
+	s, err = Rows(b, rows)
+	assert.Nil(t, err, "Unexpected Error")
+	_, err = s.Value(Min)
+	assert.Equal(t, err, value.ErrNotComparable)
+}
+
+func TestColumnEmpty(t *testing.T) {
+	s, err := Column(block.New(0))
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Count, uint(0))
+	assert.Nil(t, s.Min)
+	avg, err := s.Avg()
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, avg, 0.0)
+}
+
+func TestIntSumOverflowMovesToFloat(t *testing.T) {
+	b := block.New(10)
+	b.Append(int64(1) << 62)
+	b.Append(int64(1) << 62)
+	b.Append(int64(1)<<62 + 1)
+
+	s, _ := Column(b)
+	_, err := s.IntSum()
+	assert.Equal(t, err, value.ErrNotNumeric, "Expected the int sum to have overflowed")
+	sum, _ := s.Sum()
+	assert.Equal(t, sum, 3*float64(int64(1)<<62))
+}
+
+func TestRows(t *testing.T) {
+	b := block.New(10)
+	for i := 0; i < 100; i++ {
+		b.Append(i / 10)
+	}
+
+	rows := rowindex.New()
+	rows.Append(5, 10)   // five rows of 0 and five rows of 1
+	rows.Append(95, 100) // only the last five rows are in the block
+
+	s, err := Rows(b, rows)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, s.Rows, uint(15))
+	sum, _ := s.IntSum()
+	assert.Equal(t, sum, int64(5*0+5*1+5*9))
+	assert.Equal(t, s.Min, 0)
+	assert.Equal(t, s.Max, 9)
+}
+
+func BenchmarkColumnSum(b *testing.B) {
+	blk := block.New(b.N)
+	for i := 0; i < b.N; i++ {
+		blk.Append(i / 1000)
+	}
+
+	b.ResetTimer()
+	s, _ := Column(blk)
+	s.Sum()
+}
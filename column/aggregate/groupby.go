@@ -0,0 +1,120 @@
+package aggregate
+
+import (
+	"errors"
+
+	"github.com/lummie/golib/column/block"
+)
+
+var ErrMisaligned = errors.New("aggregate: key and value columns have different row counts")
+
+// aggregate function selector used by GroupBy
+type Func int
+
+const (
+	Count Func = iota
+	Sum
+	Min
+	Max
+	Avg
+)
+
+// Group holds the aggregate of the value rows sharing a key
+type Group struct {
+	Key   interface{} // the key shared by every row in the group
+	Value interface{} // result of the aggregate function
+	Stats *Stats      // all the aggregates for the group
+}
+
+// a run captured from a Block
+type run struct {
+	length uint
+	value  interface{}
+}
+
+// aggregates the rows of values grouped by the value held in the same row of keys
+// the runs of both columns are walked together so rows are only visited once per aligned segment of runs
+// groups are returned in the order their key first appears
+func GroupBy(keys *block.Block, values *block.Block, agg Func) ([]Group, error) {
+	keyRuns, keyRows := runs(keys)
+	valueRuns, valueRows := runs(values)
+	if keyRows != valueRows {
+		return nil, ErrMisaligned
+	}
+
+	groups := []Group{}
+	positions := make(map[interface{}]int)
+
+	var row, keyUsed, valueUsed uint
+	for k, v := 0, 0; k < len(keyRuns) && v < len(valueRuns); {
+		key := keyRuns[k]
+		val := valueRuns[v]
+
+		// the segment ends at whichever of the two runs ends first
+		length := key.length - keyUsed
+		if rest := val.length - valueUsed; rest < length {
+			length = rest
+		}
+
+		pos, found := positions[key.value]
+		if !found {
+			pos = len(groups)
+			positions[key.value] = pos
+			groups = append(groups, Group{Key: key.value, Stats: &Stats{}})
+		}
+		groups[pos].Stats.addRun(row, length, val.value)
+
+		row += length
+		keyUsed += length
+		valueUsed += length
+		if keyUsed == key.length {
+			k++
+			keyUsed = 0
+		}
+		if valueUsed == val.length {
+			v++
+			valueUsed = 0
+		}
+	}
+
+	for i := range groups {
+		result, err := groups[i].Stats.Value(agg)
+		if err != nil {
+			return nil, err
+		}
+		groups[i].Value = result
+	}
+	return groups, nil
+}
+
+// returns the result of the aggregate function
+// Sum returns an int64 when every value was an integer, otherwise a float64
+func (s *Stats) Value(agg Func) (interface{}, error) {
+	switch agg {
+	case Count:
+		return s.Count, nil
+	case Sum:
+		if sum, err := s.IntSum(); err == nil {
+			return sum, nil
+		}
+		return s.Sum()
+	case Min:
+		return s.Min, s.err
+	case Max:
+		return s.Max, s.err
+	case Avg:
+		return s.Avg()
+	}
+	return nil, errors.New("aggregate: unknown aggregate function")
+}
+
+// captures the runs of a Block returning them with the number of rows they hold
+func runs(b *block.Block) ([]run, uint) {
+	result := []run{}
+	var rows uint
+	b.IterateRuns(func(index uint, length uint, value interface{}) {
+		result = append(result, run{length: length, value: value})
+		rows += length
+	})
+	return result, rows
+}
@@ -0,0 +1,83 @@
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/value"
+)
+
+// builds a key and value column where the runs of the two columns do not line up
+func groupColumns() (*block.Block, *block.Block) {
+	keys := block.New(10)
+	values := block.New(10)
+	for _, k := range []string{"a", "a", "a", "b", "b", "a", "c", "c"} {
+		keys.Append(k)
+	}
+	for _, v := range []int{1, 1, 2, 2, 2, 2, 3, 4} {
+		values.Append(v)
+	}
+	return keys, values
+}
+
+func TestGroupBySum(t *testing.T) {
+	keys, values := groupColumns()
+	groups, err := GroupBy(keys, values, Sum)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, len(groups), 3)
+
+	assert.Equal(t, groups[0].Key, "a")
+	assert.Equal(t, groups[0].Value, int64(6))
+	assert.Equal(t, groups[1].Key, "b")
+	assert.Equal(t, groups[1].Value, int64(4))
+	assert.Equal(t, groups[2].Key, "c")
+	assert.Equal(t, groups[2].Value, int64(7))
+}
+
+func TestGroupByOtherAggregates(t *testing.T) {
+	keys, values := groupColumns()
+
+	groups, _ := GroupBy(keys, values, Count)
+	assert.Equal(t, groups[0].Value, uint(4))
+
+	groups, _ = GroupBy(keys, values, Min)
+	assert.Equal(t, groups[2].Value, 3)
+
+	groups, _ = GroupBy(keys, values, Max)
+	assert.Equal(t, groups[0].Value, 2)
+
+	groups, _ = GroupBy(keys, values, Avg)
+	assert.Equal(t, groups[0].Value, 1.5)
+	assert.Equal(t, groups[0].Stats.Rows, uint(4))
+}
+
+func TestGroupByMisaligned(t *testing.T) {
+	keys, values := groupColumns()
+	values.Append(5)
+	_, err := GroupBy(keys, values, Sum)
+	assert.Equal(t, err, ErrMisaligned)
+}
+
+func TestGroupByNonNumericSum(t *testing.T) {
+	keys, _ := groupColumns()
+	_, err := GroupBy(keys, keys, Sum)
+	assert.NotNil(t, err, "Expected an error summing strings")
+}
+
+func TestGroupByMixedTypes(t *testing.T) {
+	keys := block.New(10)
+	keys.AppendMany("a", "a", "b")
+	values := block.New(10)
+	values.AppendMany(1, "x", 2)
+
+	// COUNT never compares values so a group holding mixed types can be counted
+	groups, err := GroupBy(keys, values, Count)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, len(groups), 2)
+	assert.Equal(t, groups[0].Value, uint(2))
+	assert.Equal(t, groups[1].Value, uint(1))
+
+	_, err = GroupBy(keys, values, Min)
+	assert.Equal(t, err, value.ErrNotComparable)
+}
@@ -5,14 +5,19 @@ import (
 	"encoding/gob"
 	"errors"
 	"io"
+	"sort"
 	"sync"
+
+	"github.com/lummie/golib/column"
 )
 
 type Block struct {
 	sync.RWMutex
 	data       []*rlBlock
-	blockCount uint // number of blocks added
-	rowCount   uint // number of rows stored
+	blockCount uint        // number of blocks added
+	rowCount   uint        // number of rows stored
+	index      *ValueIndex // optional index of the rows holding each value, nil unless enabled
+	zones      *zoneMap    // optional min and max of groups of runs, nil unless enabled
 }
 
 type rlBlock struct {
@@ -31,45 +36,88 @@ func New(capacity int) *Block {
 	}
 }
 
+// returns the number of rows stored in the Block
+func (r *Block) RowCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+	return r.rowCount
+}
+
+// returns the number of run length encoded blocks stored in the Block
+func (r *Block) BlockCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+	return r.blockCount
+}
+
+// appends a row to the Block, returning the row index of the value
 func (r *Block) Append(value interface{}) uint {
-	// check if the list is empty and if so add the new rlBlock
-	if len(r.data) == 0 {
-		newBlock := &rlBlock{
-			RowIndex: 0,
-			Length:   1,
-			Value:    value,
-		}
+	r.Lock() // lock for write
+	defer r.Unlock()
+	return r.appendRunLocked(value, 1)
+}
+
+// appends each value as a row of the Block, taking the lock once for the whole batch
+// returns the row index of the first value appended, or the number of rows if there are no values
+func (r *Block) AppendMany(values ...interface{}) uint {
+	r.Lock()
+	defer r.Unlock()
 
-		r.Lock()
-		defer r.Unlock()
-		r.data = append(r.data, newBlock)
-		r.blockCount += 1 // increment the number of blocks stored
-		r.rowCount += 1   // increment the number of rows
-		return newBlock.RowIndex
+	first := r.rowCount
+	for _, value := range values {
+		r.appendRunLocked(value, 1)
 	}
+	return first
+}
 
-	r.Lock() // lock for write
+// appends count rows all holding value in a single step, extending the last rlBlock if it holds the same value
+// returns the row index of the first row appended, or the number of rows if count is 0
+func (r *Block) AppendRun(value interface{}, count uint) uint {
+	r.Lock()
 	defer r.Unlock()
 
-	// get the lastblock
-	lastBlock := r.data[len(r.data)-1]
-	if lastBlock.Value == value {
-		// the value in the lastBlock is the same as the value to store so just increment then Length
-		lastBlock.Length += 1
-		r.rowCount += 1 // increment the number of rows
-		return lastBlock.RowIndex + lastBlock.Length - 1
+	first := r.rowCount
+	if count > 0 {
+		r.appendRunLocked(value, count)
+	}
+	return first
+}
+
+// appends count rows holding value, extending the last rlBlock if it holds the same value
+// returns the row index of the last row appended, the caller must hold the write lock and count must be at least 1
+func (r *Block) appendRunLocked(value interface{}, count uint) uint {
+	if r.index != nil {
+		r.index.Lock()
+		r.index.add(value, r.rowCount, count)
+		r.index.Unlock()
 	}
 
-	// the value is the lastBlock is different so we need to add a new rlBlock
-	newBlock := &rlBlock{
-		RowIndex: lastBlock.RowIndex + lastBlock.Length,
-		Length:   1,
-		Value:    value,
+	// check if the list is empty or the value differs from the lastBlock and if so add a new rlBlock
+	if len(r.data) == 0 || r.data[len(r.data)-1].Value != value {
+		var rowIndex uint
+		if len(r.data) > 0 {
+			lastBlock := r.data[len(r.data)-1]
+			rowIndex = lastBlock.RowIndex + lastBlock.Length
+		}
+		newBlock := &rlBlock{
+			RowIndex: rowIndex,
+			Length:   count,
+			Value:    value,
+		}
+		r.data = append(r.data, newBlock) // add the new rlBlock
+		r.blockCount += 1                 // increment the number of blocks stored
+		r.rowCount += count               // increment the number of rows
+		if r.zones != nil {
+			r.zones.addRun(len(r.data)-1, value)
+		}
+		return newBlock.RowIndex + count - 1
 	}
-	r.data = append(r.data, newBlock) // add the new rlBlock
-	r.blockCount += 1                 // increment the number of blocks stored
-	r.rowCount += 1                   // increment the number of rows
-	return newBlock.RowIndex
+
+	// the value in the lastBlock is the same as the value to store so just increment then Length
+	lastBlock := r.data[len(r.data)-1]
+	lastBlock.Length += count
+	r.rowCount += count // increment the number of rows
+	return lastBlock.RowIndex + lastBlock.Length - 1
 }
 
 /*
@@ -78,22 +126,64 @@ func (r *Block) Append(value interface{}) uint {
 ----------------------------------------------------------------------------------------------------------------------------------------
 */
 
-// iterator function type delaration
-type IteratorFn func(index uint, value interface{})
+// iterator function type delaration, shared with the other column implementations
+type IteratorFn = column.IteratorFn
 
-// iterates each row in the RleList
+// iterates each row in the Block
+// the rows are read from a Snapshot so the lock is not held during the iteration and appends are not blocked,
+// rows appended during the iteration are not included
 func (r *Block) Iterate(f IteratorFn) {
-	r.RLock()
-	defer r.RUnlock()
+	r.Snapshot().Iterate(f)
+}
+
+// iterates each row in the Block from the last row to the first
+// like Iterate the rows are read from a Snapshot, so the lock is not held during the iteration
+func (r *Block) IterateReverse(f IteratorFn) {
+	r.Snapshot().IterateReverse(f)
+}
+
+// run iterator function type declaration, called once per run with the starting row, the number of rows and the value
+type RunIteratorFn = column.RunIteratorFn
+
+// iterates each run in the Block
+// like Iterate the runs are read from a Snapshot, so the lock is not held during the iteration
+func (r *Block) IterateRuns(f RunIteratorFn) {
+	r.Snapshot().IterateRuns(f)
+}
+
+// iterates the runs covering the rows index to index+length-1
+// the first and last runs are clipped so only rows inside the range are reported
+// like IterateRuns the runs are read from a Snapshot, so the lock is not held during the iteration
+func (r *Block) IterateRunsRange(index uint, length uint, f RunIteratorFn) {
+	s := r.Snapshot()
+
+	end := index + length
+	if end > s.rowCount {
+		end = s.rowCount
+	}
 
-	for _, b := range r.data {
-		for row := uint(0); row < b.Length; row++ {
-			// call the iterator function for the Length of the rlBlock
-			f(b.RowIndex+row, b.Value)
+	for i := s.runIndex(index); i < s.runCount() && s.run(i).RowIndex < end; i++ {
+		b := s.run(i)
+		start := b.RowIndex
+		if start < index {
+			start = index
+		}
+		stop := b.RowIndex + b.Length
+		if stop > end {
+			stop = end
 		}
+		f(start, stop-start, b.Value)
 	}
 }
 
+// returns the position in data of the run containing row, or len(data) if the row is not stored
+// the caller must hold the lock
+func (r *Block) runIndex(row uint) int {
+	return sort.Search(len(r.data), func(i int) bool {
+		return r.data[i].RowIndex+r.data[i].Length > row
+	})
+}
+
 /*
 ----------------------------------------------------------------------------------------------------------------------------------------
 	PERSISTENCE
@@ -171,11 +261,10 @@ func (r *Block) Write(writer io.Writer) error {
 	return nil
 }
 
-// reads the RleList from a Reader, overwriting the current contents
-// if an error occurs the RleList will be initialised to empty
+// reads the Block from a Reader, overwriting the current contents
+// the stream is decoded into a temporary Block which is only swapped in once it has been read in full,
+// so if an error occurs the current contents are left unchanged
 func (r *Block) Read(reader io.Reader) error {
-	r.Lock()
-	defer r.Unlock()
 	dec := gob.NewDecoder(reader)
 
 	// check we are decoding the correct type
@@ -189,10 +278,30 @@ func (r *Block) Read(reader io.Reader) error {
 		return errors.New("Tried to load a stream that is not RLEARRAY")
 	}
 
-	// decode rowCount
-	err = dec.Decode(&r)
+	// decode the rows, the lock is not needed until they are swapped in
+	decoded := &Block{}
+	err = dec.Decode(decoded)
 	if err != nil {
 		return err
 	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.replaceLocked(decoded)
 	return nil
 }
+
+// replaces the rows of the Block with the rows of decoded, the caller must hold the write lock
+func (r *Block) replaceLocked(decoded *Block) {
+	r.data = decoded.data
+	r.rowCount = decoded.rowCount
+	r.blockCount = decoded.blockCount
+
+	// the stored rows have been replaced so any value index or zone map must be rebuilt
+	if r.index != nil {
+		r.rebuildIndexLocked()
+	}
+	if r.zones != nil {
+		r.rebuildZoneMapLocked()
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/columntest"
 	"math/rand"
 	"os"
 	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -58,6 +61,46 @@ func TestIteratorItemsInBlocks(t *testing.T) {
 	})
 }
 
+func TestIterateRuns(t *testing.T) {
+	list := New(10)
+	list.Append("Value 1")
+	list.Append("Value 1")
+	list.Append("Value 2")
+
+	var runs []rlBlock
+	list.IterateRuns(func(index uint, length uint, value interface{}) {
+		runs = append(runs, rlBlock{RowIndex: index, Length: length, Value: value})
+	})
+	assert.Equal(t, runs, []rlBlock{{0, 2, "Value 1"}, {2, 1, "Value 2"}})
+}
+
+func TestIterateRunsRange(t *testing.T) {
+	list := New(100)
+	for i := uint(0); i < 100; i++ {
+		list.Append(i / 10)
+	}
+
+	var runs []rlBlock
+	list.IterateRunsRange(15, 20, func(index uint, length uint, value interface{}) {
+		runs = append(runs, rlBlock{RowIndex: index, Length: length, Value: value})
+	})
+	assert.Equal(t, runs, []rlBlock{{15, 5, uint(1)}, {20, 10, uint(2)}, {30, 5, uint(3)}})
+
+	// ranges extending past the end are clipped
+	runs = nil
+	list.IterateRunsRange(98, 10, func(index uint, length uint, value interface{}) {
+		runs = append(runs, rlBlock{RowIndex: index, Length: length, Value: value})
+	})
+	assert.Equal(t, runs, []rlBlock{{98, 2, uint(9)}})
+
+	// ranges starting past the end report nothing
+	runs = nil
+	list.IterateRunsRange(100, 10, func(index uint, length uint, value interface{}) {
+		runs = append(runs, rlBlock{RowIndex: index, Length: length, Value: value})
+	})
+	assert.Equal(t, len(runs), 0)
+}
+
 func TestIteratorReadWriteEmpty(t *testing.T) {
 	list := New(100)
 	buf := new(bytes.Buffer)
@@ -138,6 +181,98 @@ func TestIteratorReadWriteWithItemsInManyBlocks(t *testing.T) {
 
 }
 
+func TestAppendMany(t *testing.T) {
+	list := New(10)
+	list.Append("Value 1")
+
+	index := list.AppendMany("Value 1", "Value 2", "Value 2", "Value 3")
+	assert.Equal(t, index, uint(1), "Expected the row index of the first value")
+	assert.Equal(t, list.RowCount(), uint(5))
+	assert.Equal(t, list.BlockCount(), uint(3))
+
+	index = list.AppendMany()
+	assert.Equal(t, index, uint(5), "Expected the row count when there are no values")
+}
+
+func TestAppendRun(t *testing.T) {
+	list := New(10)
+	index := list.AppendRun("Value 1", 1000000)
+	assert.Equal(t, index, uint(0))
+
+	index = list.AppendRun("Value 1", 5)
+	assert.Equal(t, index, uint(1000000), "Expected the row index of the first row of the run")
+	assert.Equal(t, list.BlockCount(), uint(1), "Expected an equal value to extend the last run")
+
+	index = list.AppendRun("Value 2", 2)
+	assert.Equal(t, index, uint(1000005))
+	index = list.AppendRun("Value 3", 0)
+	assert.Equal(t, index, uint(1000007), "Expected the row count when count is 0")
+
+	assert.Equal(t, list.RowCount(), uint(1000007))
+	assert.Equal(t, list.BlockCount(), uint(2))
+	checkRuns(t, list)
+}
+
+// checks the runs are contiguous, that adjacent runs differ and that the counts agree with the runs
+func checkRuns(t *testing.T, list *Block) {
+	var rows uint
+	for i, b := range list.data {
+		if b.RowIndex != rows {
+			t.Error("Run", i, "starts at row", b.RowIndex, "expected", rows)
+		}
+		if i > 0 && list.data[i-1].Value == b.Value {
+			t.Error("Runs", i-1, "and", i, "hold the same value")
+		}
+		rows += b.Length
+	}
+	assert.Equal(t, list.rowCount, rows, "Expected the row count to match the runs")
+	assert.Equal(t, list.blockCount, uint(len(list.data)), "Expected the block count to match the runs")
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	const writers = 8
+	const rowsPerWriter = 2000
+
+	list := New(0)
+	// half the iterations append one row, the other half three
+	total := writers * (rowsPerWriter/2 + rowsPerWriter/2*3)
+	indexes := make(chan uint, total)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rowsPerWriter; i++ {
+				if i%2 == 0 {
+					indexes <- list.Append(w % 2)
+				} else {
+					first := list.AppendMany(w, w, i)
+					indexes <- first
+					indexes <- first + 1
+					indexes <- first + 2
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(indexes)
+
+	// every row index is returned exactly once
+	seen := make([]bool, total)
+	for index := range indexes {
+		if index >= uint(total) || seen[index] {
+			t.Fatal("Row index returned more than once or out of range", index)
+		}
+		seen[index] = true
+	}
+	assert.Equal(t, list.RowCount(), uint(total))
+	checkRuns(t, list)
+}
+
+func TestColumnConformance(t *testing.T) {
+	columntest.Run(t, func() column.Column { return New(0) })
+}
+
 const fileReadWriteTestCount int = 1000000
 
 func TestIteratorWriteToFile(t *testing.T) {
@@ -284,6 +419,39 @@ func TestIteratorReadFromEmptyFileDoesNotDestroyData(t *testing.T) {
 	os.Remove(filename)
 }
 
+func TestReadTruncatedStreamDoesNotDestroyData(t *testing.T) {
+	source := New(10)
+	source.AppendMany("Value 1", "Value 1", 2, 2.5, nil, "Value 1")
+	buf := new(bytes.Buffer)
+	err := source.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+	stream := buf.Bytes()
+
+	for offset := 0; offset < len(stream); offset++ {
+		list := New(10)
+		list.AppendMany("Existing 1", "Existing 2", "Existing 2")
+		list.EnableValueIndex()
+		list.EnableZoneMap(1)
+
+		err = list.Read(bytes.NewReader(stream[:offset]))
+		if err == nil {
+			t.Fatal("Expected an error reading a stream truncated at offset", offset)
+		}
+		assert.Equal(t, valuesOf(list), []interface{}{"Existing 1", "Existing 2", "Existing 2"}, "Offset", offset)
+		assert.Equal(t, list.RowCount(), uint(3), "Offset", offset)
+		assert.Equal(t, list.BlockCount(), uint(2), "Offset", offset)
+		assert.Equal(t, list.ValueIndex().Eq("Existing 2").RowCount(), uint(2), "Offset", offset)
+		assert.Equal(t, len(list.Zones()), 2, "Offset", offset)
+	}
+
+	// the complete stream replaces the contents
+	list := New(10)
+	list.Append("Existing")
+	err = list.Read(bytes.NewReader(stream))
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, valuesOf(list), []interface{}{"Value 1", "Value 1", 2, 2.5, nil, "Value 1"})
+}
+
 /*
 	BENCH MARKING -----------------------------------------------------------------------
 */
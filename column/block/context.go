@@ -0,0 +1,60 @@
+package block
+
+import (
+	"context"
+	"io"
+)
+
+// iterates each row in the Block, returning ctx.Err() if the context is cancelled before every row has been visited
+// the context is checked between runs and every cancelCheckInterval rows within a long run
+func (r *Block) IterateContext(ctx context.Context, f IteratorFn) error {
+	return r.Snapshot().IterateContext(ctx, f)
+}
+
+// iterates each run in the Block, returning ctx.Err() if the context is cancelled before every run has been visited
+func (r *Block) IterateRunsContext(ctx context.Context, f RunIteratorFn) error {
+	return r.Snapshot().IterateRunsContext(ctx, f)
+}
+
+// like ScanRange, but returns ctx.Err() if the context is cancelled before the scan completes
+func (r *Block) ScanRangeContext(ctx context.Context, min interface{}, max interface{}, f IteratorFn) error {
+	return r.scanRange(ctx, min, max, f)
+}
+
+// like Read, but returns ctx.Err() if the context is cancelled before the stream has been decoded
+// the context is checked before each read from the underlying reader. RLEARRAY stores every run in a single gob
+// message, so unlike RleList.ReadContext the context can't be checked between runs: once the last read from the
+// reader has returned, as it may for a stream held in memory, the runs are decoded in full whatever the context
+func (r *Block) ReadContext(ctx context.Context, reader io.Reader) error {
+	return r.Read(&contextReader{ctx: ctx, reader: reader})
+}
+
+// iterates each row in the snapshot, returning ctx.Err() if the context is cancelled before every row has been visited
+func (r *Snapshot) IterateContext(ctx context.Context, f IteratorFn) error {
+	return r.iteratePartition(ctx, partition{first: 0, end: r.runCount()}, f)
+}
+
+// iterates each run in the snapshot, returning ctx.Err() if the context is cancelled before every run has been visited
+func (r *Snapshot) IterateRunsContext(ctx context.Context, f RunIteratorFn) error {
+	for i := 0; i < r.runCount(); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b := r.run(i)
+		f(b.RowIndex, b.Length, b.Value)
+	}
+	return nil
+}
+
+// a reader that fails with ctx.Err() once the context has been cancelled
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
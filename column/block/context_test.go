@@ -0,0 +1,137 @@
+package block
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestIterateContext(t *testing.T) {
+	list := New(10)
+	list.AppendMany("Value 1", "Value 1", "Value 2")
+
+	values := []interface{}{}
+	err := list.IterateContext(context.Background(), func(index uint, value interface{}) {
+		values = append(values, value)
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, values, []interface{}{"Value 1", "Value 1", "Value 2"})
+
+	runs := 0
+	err = list.IterateRunsContext(context.Background(), func(index uint, length uint, value interface{}) {
+		runs++
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, runs, 2)
+}
+
+func TestIterateContextCancelled(t *testing.T) {
+	list := New(10)
+	list.AppendRun("Value", 100000)
+	list.AppendRun("Other", 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := list.IterateContext(ctx, func(index uint, value interface{}) {
+		if count == 0 {
+			cancel()
+		}
+		count++
+	})
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, count, cancelCheckInterval, "Expected the iteration to stop within the long run")
+
+	err = list.IterateRunsContext(ctx, func(index uint, length uint, value interface{}) {
+		t.Error("Unexpected run", index)
+	})
+	assert.Equal(t, err, context.Canceled)
+}
+
+func TestScanRangeContextCancelled(t *testing.T) {
+	list := New(10)
+	list.AppendRun(1, 5000)
+	list.AppendRun(2, 5000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := list.ScanRangeContext(ctx, 1, 2, func(index uint, value interface{}) {
+		cancel()
+		count++
+	})
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, count, cancelCheckInterval)
+
+	count = 0
+	err = list.ScanRangeContext(context.Background(), 2, nil, func(index uint, value interface{}) {
+		count++
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, count, 5000)
+}
+
+func TestReadContext(t *testing.T) {
+	list := New(10)
+	list.AppendMany("Value 1", "Value 2", "Value 2")
+
+	buf := new(bytes.Buffer)
+	err := list.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	read := New(0)
+	err = read.ReadContext(context.Background(), bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, valuesOf(read), []interface{}{"Value 1", "Value 2", "Value 2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	read = New(0)
+	read.Append("Existing")
+	err = read.ReadContext(ctx, bytes.NewReader(buf.Bytes()))
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, valuesOf(read), []interface{}{"Existing"}, "Expected a cancelled read to leave the Block unchanged")
+}
+
+// a reader that delivers its data a few bytes at a time, calling onRead before each read
+type smallReader struct {
+	data   []byte
+	reads  int
+	onRead func(reads int)
+}
+
+func (r *smallReader) Read(p []byte) (int, error) {
+	r.reads++
+	r.onRead(r.reads)
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), 16)], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadContextCancelledPartWayThroughTheStream(t *testing.T) {
+	list := New(0)
+	for i := 0; i < 100; i++ {
+		list.Append(i)
+	}
+	buf := new(bytes.Buffer)
+	err := list.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	// cancelling while the runs are still being read stops the decode at the next read
+	ctx, cancel := context.WithCancel(context.Background())
+	read := New(0)
+	read.Append("Existing")
+	reader := &smallReader{data: buf.Bytes(), onRead: func(reads int) {
+		if reads == 3 {
+			cancel()
+		}
+	}}
+	err = read.ReadContext(ctx, reader)
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, reader.reads, 3, "Expected no reads after the cancellation")
+	assert.Equal(t, valuesOf(read), []interface{}{"Existing"}, "Expected a cancelled read to leave the Block unchanged")
+}
@@ -0,0 +1,13 @@
+package block
+
+import "github.com/lummie/golib/column"
+
+// creates a Block holding the same rows as list, copying it run by run
+// list is usually an *rlelist.RleList, but any column.Column can be converted
+func FromRleList(list column.Column) *Block {
+	result := New(int(list.BlockCount()))
+	list.IterateRuns(func(index uint, length uint, value interface{}) {
+		result.AppendRun(value, length)
+	})
+	return result
+}
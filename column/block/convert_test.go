@@ -0,0 +1,21 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/rlelist"
+)
+
+func TestFromRleList(t *testing.T) {
+	list := rlelist.New()
+	list.AppendMany("a", "a", nil, 3)
+	list.AppendRun(3, 1000)
+
+	b := FromRleList(list)
+	assert.Equal(t, b.RowCount(), uint(1004))
+	assert.Equal(t, b.BlockCount(), uint(3))
+	assert.Equal(t, valuesOf(b)[:4], []interface{}{"a", "a", nil, 3})
+
+	assert.Equal(t, FromRleList(rlelist.New()).RowCount(), uint(0))
+}
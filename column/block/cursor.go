@@ -0,0 +1,107 @@
+package block
+
+import "sort"
+
+// Cursor is a position within the rows of a Snapshot
+// it moves run by run, keeping the current run and the offset of the row within it, so no rows are expanded.
+// A new Cursor is not on a row, Next moves it to the first row and Prev to the last, so rows can be walked in either order.
+type Cursor struct {
+	snapshot *Snapshot
+	run      int  // position of the current run, -1 before the first row and runCount() after the last
+	offset   uint // offset of the current row within the run
+	moved    bool // false until the cursor first moves, so Prev starts from the last row
+}
+
+// returns a Cursor over the rows currently stored in the Block
+// like Iterate the Cursor reads a Snapshot, so rows appended later are not seen and no lock is held
+func (r *Block) Cursor() *Cursor {
+	return r.Snapshot().Cursor()
+}
+
+// returns a Cursor over the rows of the snapshot
+func (r *Snapshot) Cursor() *Cursor {
+	return &Cursor{snapshot: r, run: -1}
+}
+
+// moves to the next row, returning false if there are no more rows
+func (r *Cursor) Next() bool {
+	r.moved = true
+	runs := r.snapshot.runCount()
+	if r.run >= runs {
+		return false
+	}
+	if r.run >= 0 && r.offset+1 < r.snapshot.run(r.run).Length {
+		r.offset++
+		return true
+	}
+	r.run++
+	r.offset = 0
+	return r.run < runs
+}
+
+// moves to the previous row, returning false if there are no earlier rows
+func (r *Cursor) Prev() bool {
+	if !r.moved {
+		r.moved = true
+		r.run = r.snapshot.runCount()
+	}
+	if r.run < 0 {
+		return false
+	}
+	if r.run < r.snapshot.runCount() && r.offset > 0 {
+		r.offset--
+		return true
+	}
+	r.run--
+	if r.run < 0 {
+		return false
+	}
+	r.offset = r.snapshot.run(r.run).Length - 1
+	return true
+}
+
+// moves to row, returning false and moving past the last row if the row is not stored
+func (r *Cursor) Seek(row uint) bool {
+	r.moved = true
+	runs := r.snapshot.runCount()
+	r.run = sort.Search(runs, func(i int) bool {
+		b := r.snapshot.run(i)
+		return b.RowIndex+b.Length > row
+	})
+	if r.run == runs {
+		r.offset = 0
+		return false
+	}
+	r.offset = row - r.snapshot.run(r.run).RowIndex
+	return true
+}
+
+// returns true if the cursor is positioned on a row
+func (r *Cursor) Valid() bool {
+	return r.run >= 0 && r.run < r.snapshot.runCount()
+}
+
+// returns the index of the current row, or 0 if the cursor is not positioned on a row
+func (r *Cursor) Row() uint {
+	if !r.Valid() {
+		return 0
+	}
+	return r.snapshot.run(r.run).RowIndex + r.offset
+}
+
+// returns the value of the current row, or nil if the cursor is not positioned on a row
+func (r *Cursor) Value() interface{} {
+	if !r.Valid() {
+		return nil
+	}
+	return r.snapshot.run(r.run).Value
+}
+
+// returns the run holding the current row, or an empty Run if the cursor is not positioned on a row
+func (r *Cursor) Run() Run {
+	if !r.Valid() {
+		return Run{}
+	}
+	b := r.snapshot.run(r.run)
+	return Run{Index: b.RowIndex, Length: b.Length, Value: b.Value}
+}
@@ -0,0 +1,90 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestIterateReverse(t *testing.T) {
+	list := New(10)
+	list.AppendMany("a", "a", "b", "c")
+
+	indexes := []uint{}
+	values := []interface{}{}
+	list.IterateReverse(func(index uint, value interface{}) {
+		indexes = append(indexes, index)
+		values = append(values, value)
+	})
+	assert.Equal(t, indexes, []uint{3, 2, 1, 0})
+	assert.Equal(t, values, []interface{}{"c", "b", "a", "a"})
+}
+
+func TestCursorNextAndPrev(t *testing.T) {
+	list := New(10)
+	list.AppendMany("a", "a", "b")
+	list.AppendRun("c", 3)
+
+	c := list.Cursor()
+	assert.Equal(t, c.Valid(), false, "Expected a new cursor not to be on a row")
+	values := []interface{}{}
+	for c.Next() {
+		assert.Equal(t, c.Row(), uint(len(values)))
+		values = append(values, c.Value())
+	}
+	assert.Equal(t, values, []interface{}{"a", "a", "b", "c", "c", "c"})
+	assert.Equal(t, c.Next(), false)
+	assert.Equal(t, c.Value(), nil)
+
+	// moving back from past the last row walks the rows in reverse
+	values = []interface{}{}
+	for c.Prev() {
+		values = append(values, c.Value())
+	}
+	assert.Equal(t, values, []interface{}{"c", "c", "c", "b", "a", "a"})
+	assert.Equal(t, c.Row(), uint(0), "Expected row 0 before the first row")
+
+	assert.Equal(t, list.Cursor().Prev(), true, "Expected Prev on a new cursor to move to the last row")
+}
+
+func TestCursorSeek(t *testing.T) {
+	list := New(10)
+	list.AppendRun("a", 1000)
+	list.AppendRun("b", 1000)
+
+	c := list.Cursor()
+	assert.Equal(t, c.Seek(1500), true)
+	assert.Equal(t, c.Value(), "b")
+	assert.Equal(t, c.Run(), Run{Index: 1000, Length: 1000, Value: "b"})
+
+	assert.Equal(t, c.Seek(1000), true)
+	assert.Equal(t, c.Prev(), true)
+	assert.Equal(t, c.Row(), uint(999))
+	assert.Equal(t, c.Value(), "a")
+
+	assert.Equal(t, c.Seek(2000), false)
+	assert.Equal(t, c.Valid(), false)
+	assert.Equal(t, c.Prev(), true)
+	assert.Equal(t, c.Row(), uint(1999))
+}
+
+func TestCursorEmptyBlock(t *testing.T) {
+	c := New(0).Cursor()
+	assert.Equal(t, c.Next(), false)
+	assert.Equal(t, c.Prev(), false)
+	assert.Equal(t, c.Seek(0), false)
+	assert.Equal(t, c.Run(), Run{})
+}
+
+func TestCursorIgnoresLaterAppends(t *testing.T) {
+	list := New(10)
+	list.Append("a")
+	c := list.Cursor()
+	list.Append("b")
+
+	count := 0
+	for c.Next() {
+		count++
+	}
+	assert.Equal(t, count, 1, "Expected the cursor to read the rows present when it was created")
+}
@@ -0,0 +1,27 @@
+package block
+
+// ValueCount holds a distinct value stored in a Block and the number of rows holding it
+type ValueCount struct {
+	Value interface{}
+	Count uint
+}
+
+// returns each distinct value stored in the Block with the number of rows holding it
+// values are returned in the order they first appear, each run is visited once so the rows are never expanded
+func (r *Block) Distinct() []ValueCount {
+	r.RLock()
+	defer r.RUnlock()
+
+	result := []ValueCount{}
+	positions := make(map[interface{}]int)
+	for _, b := range r.data {
+		pos, found := positions[b.Value]
+		if !found {
+			pos = len(result)
+			positions[b.Value] = pos
+			result = append(result, ValueCount{Value: b.Value})
+		}
+		result[pos].Count += b.Length
+	}
+	return result
+}
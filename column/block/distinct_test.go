@@ -0,0 +1,30 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestDistinct(t *testing.T) {
+	list := New(10)
+	list.Append("Value 1")
+	list.Append("Value 1")
+	list.Append("Value 2")
+	list.Append(nil)
+	list.Append("Value 1")
+	list.Append("Value 3")
+	list.Append("Value 2")
+
+	assert.Equal(t, list.Distinct(), []ValueCount{
+		{"Value 1", 3},
+		{"Value 2", 2},
+		{nil, 1},
+		{"Value 3", 1},
+	})
+}
+
+func TestDistinctEmpty(t *testing.T) {
+	list := New(10)
+	assert.Equal(t, len(list.Distinct()), 0)
+}
@@ -0,0 +1,232 @@
+package block
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/lummie/golib/column/rowindex"
+)
+
+var ErrIndexMismatch = errors.New("block: value index does not cover the rows stored in the block")
+
+// ValueIndex is an inverted index mapping each distinct value stored in a Block to the rows holding it
+// rows are held as ranges matching the runs of the Block, so the index grows with the number of runs rather than rows
+type ValueIndex struct {
+	sync.RWMutex
+	values   map[interface{}]*rowindex.RowIndex // rows holding each value
+	rowCount uint                               // number of rows covered by the index
+}
+
+// an entry of the persisted index
+type indexEntry struct {
+	Value  interface{} // the indexed value
+	Ranges []uint      // pairs of starting row and length
+}
+
+func newValueIndex() *ValueIndex {
+	return &ValueIndex{
+		values: make(map[interface{}]*rowindex.RowIndex),
+	}
+}
+
+// enables the value index for the Block, indexing the rows already stored
+// once enabled the index is maintained on every append, calling it again returns the existing index
+func (r *Block) EnableValueIndex() *ValueIndex {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.index == nil {
+		r.index = newValueIndex()
+		r.rebuildIndexLocked()
+	}
+	return r.index
+}
+
+// returns the value index of the Block or nil if it has not been enabled
+func (r *Block) ValueIndex() *ValueIndex {
+	r.RLock()
+	defer r.RUnlock()
+	return r.index
+}
+
+// indexes every run stored in the Block, the caller must hold the write lock and the index must be enabled
+func (r *Block) rebuildIndexLocked() {
+	index := newValueIndex()
+	for _, b := range r.data {
+		index.add(b.Value, b.RowIndex, b.Length)
+	}
+
+	r.index.Lock()
+	defer r.index.Unlock()
+	r.index.values = index.values
+	r.index.rowCount = index.rowCount
+}
+
+// records that length rows from index hold value
+func (r *ValueIndex) add(value interface{}, index uint, length uint) {
+	rows, found := r.values[value]
+	if !found {
+		rows = rowindex.New()
+		r.values[value] = rows
+	}
+	rows.Extend(index, length)
+	r.rowCount += length
+}
+
+// returns the rows holding value
+func (r *ValueIndex) Eq(value interface{}) *rowindex.RowIndex {
+	return r.In(value)
+}
+
+// returns the rows holding any of the values, as ranges in ascending row order
+func (r *ValueIndex) In(values ...interface{}) *rowindex.RowIndex {
+	r.RLock()
+	defer r.RUnlock()
+
+	matches := []*rowindex.RowIndex{}
+	for _, value := range values {
+		if rows, found := r.values[value]; found {
+			matches = append(matches, rows)
+		}
+	}
+	return rowindex.Union(matches...)
+}
+
+// returns the number of distinct values in the index
+func (r *ValueIndex) Len() int {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.values)
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	PERSISTENCE
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// writes the value index to a writer, allowing it to be stored next to the column rather than rebuilt on load
+func (r *ValueIndex) Write(writer io.Writer) error {
+	r.RLock()
+	defer r.RUnlock()
+	enc := gob.NewEncoder(writer)
+
+	err := enc.Encode("RLEINDEX")
+	if err != nil {
+		return err
+	}
+
+	err = enc.Encode(r.rowCount)
+	if err != nil {
+		return err
+	}
+
+	err = enc.Encode(uint(len(r.values)))
+	if err != nil {
+		return err
+	}
+
+	for value, rows := range r.values {
+		entry := &indexEntry{Value: value}
+		rows.Iterate(func(index uint, length uint) {
+			entry.Ranges = append(entry.Ranges, index, length)
+		})
+		err = enc.Encode(entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reads a value index written by ValueIndex.Write and attaches it to the Block, replacing any existing index
+// returns ErrIndexMismatch if the index does not match the rows stored in the Block
+func (r *Block) ReadValueIndex(reader io.Reader) error {
+	dec := gob.NewDecoder(reader)
+
+	// check we are decoding the correct type
+	var typeCheck string
+	err := dec.Decode(&typeCheck)
+	if err != nil {
+		return err
+	}
+	if typeCheck != "RLEINDEX" {
+		return errors.New("Tried to load a stream that is not RLEINDEX")
+	}
+
+	index := newValueIndex()
+	err = dec.Decode(&index.rowCount)
+	if err != nil {
+		return err
+	}
+
+	var count uint
+	err = dec.Decode(&count)
+	if err != nil {
+		return err
+	}
+
+	for i := uint(0); i < count; i++ {
+		entry := &indexEntry{}
+		err = dec.Decode(entry)
+		if err != nil {
+			return err
+		}
+		if len(entry.Ranges)%2 != 0 {
+			return ErrIndexMismatch
+		}
+		rows := rowindex.New()
+		for j := 0; j < len(entry.Ranges); j += 2 {
+			rows.Append(entry.Ranges[j], entry.Ranges[j+1])
+		}
+		index.values[entry.Value] = rows
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if index.rowCount != r.rowCount || !r.matchesIndexLocked(index) {
+		return ErrIndexMismatch
+	}
+	if r.index == nil {
+		r.index = index
+		return nil
+	}
+
+	// replace the contents of the existing index so it stays valid for anyone holding it
+	r.index.Lock()
+	defer r.index.Unlock()
+	r.index.values = index.values
+	r.index.rowCount = index.rowCount
+	return nil
+}
+
+// checks every range of the index lies within the Block and only covers runs holding the indexed value,
+// and that the ranges cover every row. The caller must hold the lock
+func (r *Block) matchesIndexLocked(index *ValueIndex) bool {
+	var covered uint
+	matches := true
+	for value, rows := range index.values {
+		rows.Iterate(func(start uint, length uint) {
+			if !matches {
+				return
+			}
+			if length == 0 || start >= r.rowCount || length > r.rowCount-start {
+				matches = false
+				return
+			}
+			for i := r.runIndex(start); i < len(r.data) && r.data[i].RowIndex < start+length; i++ {
+				if r.data[i].Value != value {
+					matches = false
+					return
+				}
+			}
+			covered += length
+		})
+		if !matches {
+			return false
+		}
+	}
+	return covered == r.rowCount
+}
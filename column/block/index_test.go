@@ -0,0 +1,126 @@
+package block
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/rowindex"
+)
+
+// returns the ranges held in a row set as pairs of starting row and length
+func rangesOf(rows *rowindex.RowIndex) [][2]uint {
+	result := [][2]uint{}
+	rows.Iterate(func(index uint, length uint) {
+		result = append(result, [2]uint{index, length})
+	})
+	return result
+}
+
+// creates a block with a value index enabled before the values are appended
+func indexedBlock(values ...interface{}) *Block {
+	list := New(len(values))
+	list.EnableValueIndex()
+	for _, v := range values {
+		list.Append(v)
+	}
+	return list
+}
+
+func TestValueIndexMaintainedOnAppend(t *testing.T) {
+	list := indexedBlock("a", "a", "b", "a", "c", "c", "b")
+	index := list.ValueIndex()
+	assert.NotNil(t, index, "Expected the index to be enabled")
+	assert.Equal(t, index.Len(), 3)
+
+	assert.Equal(t, rangesOf(index.Eq("a")), [][2]uint{{0, 2}, {3, 1}})
+	assert.Equal(t, rangesOf(index.Eq("c")), [][2]uint{{4, 2}})
+	assert.Equal(t, rangesOf(index.Eq("missing")), [][2]uint{})
+	assert.Equal(t, rangesOf(index.In("a", "b")), [][2]uint{{0, 4}, {6, 1}})
+}
+
+func TestValueIndexEnabledOnExistingRows(t *testing.T) {
+	list := New(10)
+	list.Append(1)
+	list.Append(1)
+	list.Append(2)
+	assert.Nil(t, list.ValueIndex(), "Expected no index before it is enabled")
+
+	index := list.EnableValueIndex()
+	assert.Equal(t, list.EnableValueIndex(), index, "Expected enabling twice to return the same index")
+	list.Append(1)
+	assert.Equal(t, rangesOf(index.Eq(1)), [][2]uint{{0, 2}, {3, 1}})
+}
+
+func TestValueIndexWriteRead(t *testing.T) {
+	list := indexedBlock("a", "a", nil, "b", "a")
+	buf := new(bytes.Buffer)
+	err := list.ValueIndex().Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	loaded := New(10)
+	for _, v := range []interface{}{"a", "a", nil, "b", "a"} {
+		loaded.Append(v)
+	}
+	err = loaded.ReadValueIndex(buf)
+	assert.Nil(t, err, "Unexpected Read Error")
+	index := loaded.ValueIndex()
+	assert.Equal(t, rangesOf(index.Eq("a")), [][2]uint{{0, 2}, {4, 1}})
+	assert.Equal(t, rangesOf(index.Eq(nil)), [][2]uint{{2, 1}})
+
+	// the loaded index is maintained on append
+	loaded.Append("b")
+	assert.Equal(t, rangesOf(index.Eq("b")), [][2]uint{{3, 1}, {5, 1}})
+}
+
+func TestValueIndexReadMismatch(t *testing.T) {
+	list := indexedBlock("a", "b")
+	buf := new(bytes.Buffer)
+	list.ValueIndex().Write(buf)
+
+	other := New(10)
+	other.Append("a")
+	err := other.ReadValueIndex(buf)
+	assert.Equal(t, err, ErrIndexMismatch)
+	assert.Nil(t, other.ValueIndex(), "Expected the index not to be attached")
+
+	err = other.ReadValueIndex(bytes.NewBufferString("Invalid Data"))
+	assert.NotNil(t, err, "Expected an error reading invalid data")
+}
+
+func TestValueIndexReadMismatchedValues(t *testing.T) {
+	list := indexedBlock("a", "b")
+	buf := new(bytes.Buffer)
+	list.ValueIndex().Write(buf)
+
+	// the same number of rows holding different values
+	other := indexedBlock("a", "c")
+	err := other.ReadValueIndex(buf)
+	assert.Equal(t, err, ErrIndexMismatch)
+	assert.Equal(t, rangesOf(other.ValueIndex().Eq("c")), [][2]uint{{1, 1}}, "Expected the existing index to be kept")
+
+	// ranges outside the rows of the block
+	buf.Reset()
+	enc := gob.NewEncoder(buf)
+	enc.Encode("RLEINDEX")
+	enc.Encode(uint(2))
+	enc.Encode(uint(1))
+	enc.Encode(&indexEntry{Value: "a", Ranges: []uint{1, 2}})
+	err = indexedBlock("a", "a").ReadValueIndex(buf)
+	assert.Equal(t, err, ErrIndexMismatch)
+}
+
+func TestValueIndexRebuiltOnRead(t *testing.T) {
+	source := New(10)
+	source.Append("x")
+	source.Append("y")
+	buf := new(bytes.Buffer)
+	source.Write(buf)
+
+	list := indexedBlock("a", "b")
+	err := list.Read(buf)
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, rangesOf(list.ValueIndex().Eq("a")), [][2]uint{})
+	assert.Equal(t, rangesOf(list.ValueIndex().Eq("y")), [][2]uint{{1, 1}})
+}
@@ -0,0 +1,52 @@
+package block
+
+import (
+	"iter"
+
+	"github.com/lummie/golib/column"
+)
+
+// Run is a value repeated over a contiguous range of rows
+type Run = column.Run
+
+// returns a sequence of the row index and value of each row in the Block, for use with range
+// like Iterate the rows are read from a Snapshot taken when the sequence is started, so no lock is held while ranging
+func (r *Block) All() iter.Seq2[uint, interface{}] {
+	return func(yield func(uint, interface{}) bool) {
+		r.Snapshot().All()(yield)
+	}
+}
+
+// returns a sequence of the runs in the Block, for use with range
+// like IterateRuns the runs are read from a Snapshot taken when the sequence is started
+func (r *Block) Runs() iter.Seq[Run] {
+	return func(yield func(Run) bool) {
+		r.Snapshot().Runs()(yield)
+	}
+}
+
+// returns a sequence of the row index and value of each row in the snapshot
+func (r *Snapshot) All() iter.Seq2[uint, interface{}] {
+	return func(yield func(uint, interface{}) bool) {
+		for i := 0; i < r.runCount(); i++ {
+			b := r.run(i)
+			for row := uint(0); row < b.Length; row++ {
+				if !yield(b.RowIndex+row, b.Value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// returns a sequence of the runs in the snapshot
+func (r *Snapshot) Runs() iter.Seq[Run] {
+	return func(yield func(Run) bool) {
+		for i := 0; i < r.runCount(); i++ {
+			b := r.run(i)
+			if !yield(Run{Index: b.RowIndex, Length: b.Length, Value: b.Value}) {
+				return
+			}
+		}
+	}
+}
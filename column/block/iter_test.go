@@ -0,0 +1,71 @@
+package block
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestAll(t *testing.T) {
+	list := New(10)
+	list.AppendMany("Value 1", "Value 1", "Value 2", "Value 3")
+
+	indexes := []uint{}
+	values := []interface{}{}
+	for index, value := range list.All() {
+		indexes = append(indexes, index)
+		values = append(values, value)
+	}
+	assert.Equal(t, indexes, []uint{0, 1, 2, 3})
+	assert.Equal(t, values, []interface{}{"Value 1", "Value 1", "Value 2", "Value 3"})
+}
+
+func TestAllBreak(t *testing.T) {
+	list := New(10)
+	list.AppendRun("Value", 1000)
+
+	count := 0
+	for index := range list.All() {
+		if index == 9 {
+			break
+		}
+		count++
+	}
+	assert.Equal(t, count, 9)
+
+	// the sequence holds no lock, so the Block can be appended to from the loop body
+	for _, value := range list.All() {
+		list.Append(value)
+		break
+	}
+	assert.Equal(t, list.RowCount(), uint(1001))
+}
+
+func TestAllComposesWithIteratorHelpers(t *testing.T) {
+	list := New(10)
+	list.AppendMany(1, 2, 2)
+
+	rows := maps.Collect(list.All())
+	assert.Equal(t, rows, map[uint]interface{}{0: 1, 1: 2, 2: 2})
+}
+
+func TestRuns(t *testing.T) {
+	list := New(10)
+	list.AppendMany("Value 1", "Value 1", "Value 2")
+	list.AppendRun("Value 3", 5)
+
+	runs := []Run{}
+	for run := range list.Runs() {
+		runs = append(runs, run)
+	}
+	assert.Equal(t, runs, []Run{
+		{Index: 0, Length: 2, Value: "Value 1"},
+		{Index: 2, Length: 1, Value: "Value 2"},
+		{Index: 3, Length: 5, Value: "Value 3"},
+	})
+
+	for range New(0).Runs() {
+		t.Error("Expected no runs in an empty Block")
+	}
+}
@@ -0,0 +1,209 @@
+package block
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// number of partitions created for each worker, so workers finishing early can take on more of the rows
+const partitionsPerWorker = 4
+
+// number of rows processed between checks for cancellation within a long run
+const cancelCheckInterval = 1024
+
+// map function type declaration, returns the result for a row
+type MapFn func(index uint, value interface{}) interface{}
+
+// collect function type declaration, called with the result of a row
+type CollectFn func(index uint, result interface{})
+
+// a contiguous range of runs processed by a single worker
+type partition struct {
+	first int // position of the first run
+	end   int // position after the last run
+}
+
+// the result of a row processed by ParallelMap
+type mapped struct {
+	index  uint
+	result interface{}
+}
+
+// calls f for every row using workers goroutines, each processing a contiguous range of runs
+// the rows are read from a Snapshot so appends are not blocked. f is called concurrently and in no particular order
+// if workers is less than 1 GOMAXPROCS workers are used
+// returns ctx.Err() if the context is cancelled before every row has been processed
+func (r *Block) ParallelIterate(ctx context.Context, workers int, f IteratorFn) error {
+	s := r.Snapshot()
+	return s.parallel(ctx, workers, func(p partition) error {
+		return s.iteratePartition(ctx, p, f)
+	})
+}
+
+// calls f for every row using workers goroutines and passes each result to collect
+// collect is never called concurrently, if ordered is true it is called in row order, otherwise each range of runs is
+// collected as soon as it has been processed
+// returns ctx.Err() if the context is cancelled before every row has been processed
+func (r *Block) ParallelMap(ctx context.Context, workers int, ordered bool, f MapFn, collect CollectFn) error {
+	s := r.Snapshot()
+	parts := s.partitions(workerCount(workers) * partitionsPerWorker)
+
+	// process a partition buffering the results
+	process := func(p partition) ([]mapped, error) {
+		results := []mapped{}
+		err := s.iteratePartition(ctx, p, func(index uint, value interface{}) {
+			results = append(results, mapped{index: index, result: f(index, value)})
+		})
+		return results, err
+	}
+
+	if !ordered {
+		var mu sync.Mutex
+		return s.parallelPartitions(ctx, workers, parts, func(i int) error {
+			results, err := process(parts[i])
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, m := range results {
+				collect(m.index, m.result)
+			}
+			return nil
+		})
+	}
+
+	// each partition hands its results to the collector below, which emits them in partition order
+	done := make([]chan []mapped, len(parts))
+	for i := range done {
+		done[i] = make(chan []mapped, 1)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.parallelPartitions(ctx, workers, parts, func(i int) error {
+			results, err := process(parts[i])
+			if err != nil {
+				return err
+			}
+			done[i] <- results
+			return nil
+		})
+	}()
+
+	finished := false // true once the workers have all completed without error
+	for i := 0; i < len(parts); {
+		select {
+		case results := <-done[i]:
+			for _, m := range results {
+				collect(m.index, m.result)
+			}
+			i++
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+			// every partition has been processed, carry on collecting the buffered results
+			finished = true
+			errs = nil
+		}
+	}
+	if !finished {
+		return <-errs
+	}
+	return nil
+}
+
+// runs process for each partition of the snapshot using workers goroutines
+func (r *Snapshot) parallel(ctx context.Context, workers int, process func(p partition) error) error {
+	parts := r.partitions(workerCount(workers) * partitionsPerWorker)
+	return r.parallelPartitions(ctx, workers, parts, func(i int) error {
+		return process(parts[i])
+	})
+}
+
+// runs process for each of the partitions using workers goroutines, stopping early if the context is cancelled
+func (r *Snapshot) parallelPartitions(ctx context.Context, workers int, parts []partition, process func(i int) error) error {
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range parts {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < workerCount(workers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := process(i)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// splits the runs of the snapshot into at most n contiguous partitions holding roughly equal numbers of rows
+func (r *Snapshot) partitions(n int) []partition {
+	runs := r.runCount()
+	if runs == 0 {
+		return nil
+	}
+	target := r.rowCount / uint(n)
+	if target == 0 {
+		target = 1
+	}
+
+	result := []partition{}
+	first := 0
+	var rows uint
+	for i := 0; i < runs; i++ {
+		rows += r.run(i).Length
+		if rows >= target || i == runs-1 {
+			result = append(result, partition{first: first, end: i + 1})
+			first = i + 1
+			rows = 0
+		}
+	}
+	return result
+}
+
+// calls f for each row in the partition, returning ctx.Err() if the context is cancelled
+func (r *Snapshot) iteratePartition(ctx context.Context, p partition, f IteratorFn) error {
+	for i := p.first; i < p.end; i++ {
+		b := r.run(i)
+		for row := uint(0); row < b.Length; row++ {
+			if row%cancelCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			f(b.RowIndex+row, b.Value)
+		}
+	}
+	return nil
+}
+
+// returns the number of workers to use
+func workerCount(workers int) int {
+	if workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
@@ -0,0 +1,111 @@
+package block
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+// creates a block with the number of rows given, row i holding i/10
+func parallelBlock(rows int) *Block {
+	list := New(rows / 10)
+	for i := 0; i < rows; i++ {
+		list.Append(i / 10)
+	}
+	return list
+}
+
+func TestParallelIterate(t *testing.T) {
+	list := parallelBlock(10000)
+
+	var sum int64
+	var count int64
+	err := list.ParallelIterate(context.Background(), 4, func(index uint, value interface{}) {
+		if value.(int) != int(index/10) {
+			t.Error("Unexpected value for row", index, value)
+		}
+		atomic.AddInt64(&sum, int64(index))
+		atomic.AddInt64(&count, 1)
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, count, int64(10000))
+	assert.Equal(t, sum, int64(10000*9999/2), "Expected every row exactly once")
+}
+
+func TestParallelIterateEmptyBlock(t *testing.T) {
+	err := New(0).ParallelIterate(context.Background(), 0, func(index uint, value interface{}) {
+		t.Error("Unexpected row", index)
+	})
+	assert.Nil(t, err, "Unexpected Error")
+}
+
+func TestParallelMapOrdered(t *testing.T) {
+	list := parallelBlock(5000)
+
+	next := uint(0)
+	err := list.ParallelMap(context.Background(), 8, true, func(index uint, value interface{}) interface{} {
+		return value.(int) * 2
+	}, func(index uint, result interface{}) {
+		if index != next {
+			t.Fatal("Expected row", next, "got", index)
+		}
+		assert.Equal(t, result, int(index/10)*2)
+		next++
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, next, uint(5000))
+}
+
+func TestParallelMapUnordered(t *testing.T) {
+	list := parallelBlock(5000)
+
+	seen := make([]bool, 5000)
+	collecting := int32(0)
+	err := list.ParallelMap(context.Background(), 8, false, func(index uint, value interface{}) interface{} {
+		return index
+	}, func(index uint, result interface{}) {
+		if atomic.AddInt32(&collecting, 1) != 1 {
+			t.Error("Expected collect not to be called concurrently")
+		}
+		if seen[index] || result.(uint) != index {
+			t.Error("Unexpected result for row", index)
+		}
+		seen[index] = true
+		atomic.AddInt32(&collecting, -1)
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	for i, s := range seen {
+		if !s {
+			t.Fatal("Row not collected", i)
+		}
+	}
+}
+
+func TestParallelCancellation(t *testing.T) {
+	list := New(0)
+	list.AppendRun("Value", 1000000)
+	list.AppendRun("Other", 1000000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	var count int64
+	err := list.ParallelIterate(ctx, 2, func(index uint, value interface{}) {
+		once.Do(cancel)
+		atomic.AddInt64(&count, 1)
+	})
+	assert.Equal(t, err, context.Canceled)
+	if count >= 2000000 {
+		t.Error("Expected the iteration to stop early")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	once = sync.Once{}
+	err = list.ParallelMap(ctx, 2, true, func(index uint, value interface{}) interface{} {
+		once.Do(cancel)
+		return nil
+	}, func(index uint, result interface{}) {})
+	assert.Equal(t, err, context.Canceled)
+}
@@ -0,0 +1,48 @@
+package block
+
+import (
+	"errors"
+)
+
+var ErrInvalidPermutation = errors.New("block: permutation is not a reordering of the rows in the block")
+
+// returns a new Block holding the rows reordered by perm, where perm[i] is the row placed at row i
+// if the Block has a value index or zone map enabled they are also enabled on the new Block
+// perm must contain every row of the Block exactly once, otherwise ErrInvalidPermutation is returned
+func (r *Block) ApplyPermutation(perm []uint) (*Block, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if uint(len(perm)) != r.rowCount {
+		return nil, ErrInvalidPermutation
+	}
+	seen := make([]bool, len(perm))
+	for _, row := range perm {
+		if row >= r.rowCount || seen[row] {
+			return nil, ErrInvalidPermutation
+		}
+		seen[row] = true
+	}
+
+	result := New(0)
+	run := -1
+	for _, row := range perm {
+		// permutations usually move rows in groups so check the last run used before searching
+		if run < 0 || row < r.data[run].RowIndex || row >= r.data[run].RowIndex+r.data[run].Length {
+			run = r.runIndex(row)
+		}
+		// result is not shared until it is returned so the lock is not needed
+		result.appendRunLocked(r.data[run].Value, 1)
+	}
+
+	// the result keeps the value index and zone map of the Block if they are enabled
+	if r.index != nil {
+		result.index = newValueIndex()
+		result.rebuildIndexLocked()
+	}
+	if r.zones != nil {
+		result.zones = &zoneMap{runsPerZone: r.zones.runsPerZone}
+		result.rebuildZoneMapLocked()
+	}
+	return result, nil
+}
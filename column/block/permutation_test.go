@@ -0,0 +1,74 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestApplyPermutation(t *testing.T) {
+	list := New(10)
+	for _, v := range []string{"a", "b", "a", "b", "a"} {
+		list.Append(v)
+	}
+
+	sorted, err := list.ApplyPermutation([]uint{0, 2, 4, 1, 3})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, valuesOf(sorted), []interface{}{"a", "a", "a", "b", "b"})
+	assert.Equal(t, sorted.BlockCount(), uint(2))
+	assert.Equal(t, sorted.RowCount(), uint(5))
+
+	// the original block is unchanged
+	assert.Equal(t, valuesOf(list), []interface{}{"a", "b", "a", "b", "a"})
+}
+
+func TestApplyInvalidPermutation(t *testing.T) {
+	list := New(10)
+	list.Append("a")
+	list.Append("b")
+
+	_, err := list.ApplyPermutation([]uint{0})
+	assert.Equal(t, err, ErrInvalidPermutation, "Expected an error for a short permutation")
+	_, err = list.ApplyPermutation([]uint{1, 1})
+	assert.Equal(t, err, ErrInvalidPermutation, "Expected an error for a repeated row")
+	_, err = list.ApplyPermutation([]uint{0, 2})
+	assert.Equal(t, err, ErrInvalidPermutation, "Expected an error for a row out of range")
+}
+
+func TestApplyPermutationKeepsValueIndex(t *testing.T) {
+	list := indexedBlock("b", "a", "b", "a")
+
+	sorted, err := list.ApplyPermutation([]uint{1, 3, 0, 2})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.NotNil(t, sorted.ValueIndex(), "Expected the value index to be enabled")
+	assert.Equal(t, rangesOf(sorted.ValueIndex().Eq("b")), [][2]uint{{2, 2}})
+
+	// the index is maintained on later appends
+	sorted.Append("c")
+	assert.Equal(t, rangesOf(sorted.ValueIndex().Eq("c")), [][2]uint{{4, 1}})
+
+	sorted, err = New(0).ApplyPermutation([]uint{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Nil(t, sorted.ValueIndex(), "Expected no value index")
+}
+
+func TestApplyPermutationKeepsZoneMap(t *testing.T) {
+	list := New(0)
+	list.AppendMany("b", "a", "b", "a")
+	assert.Nil(t, list.EnableZoneMap(1), "Unexpected Error")
+
+	sorted, err := list.ApplyPermutation([]uint{1, 3, 0, 2})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, sorted.Zones(), []Zone{
+		{FirstRow: 0, Rows: 2, Min: "a", Max: "a"},
+		{FirstRow: 2, Rows: 2, Min: "b", Max: "b"},
+	})
+
+	// the zone map is maintained on later appends
+	sorted.Append("c")
+	assert.Equal(t, len(sorted.Zones()), 3)
+
+	sorted, err = New(0).ApplyPermutation([]uint{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Nil(t, sorted.Zones(), "Expected no zone map")
+}
@@ -0,0 +1,99 @@
+package block
+
+import "sort"
+
+// Snapshot is an immutable view of the rows stored in a Block at the time it was taken
+// Appends only ever change the last run of a Block, so a snapshot shares every earlier run with the Block and keeps its
+// own copy of the last run. Reading a snapshot needs no lock, so long scans never stall writers.
+type Snapshot struct {
+	head       []*rlBlock // runs shared with the Block, all but the last run
+	tail       *rlBlock   // copy of the last run, nil if the Block was empty
+	rowCount   uint       // number of rows in the snapshot
+	blockCount uint       // number of runs in the snapshot
+}
+
+// returns an immutable view of the rows currently stored in the Block
+func (r *Block) Snapshot() *Snapshot {
+	r.RLock()
+	defer r.RUnlock()
+	return r.snapshotLocked()
+}
+
+// returns an immutable view of the rows currently stored in the Block, the caller must hold the lock
+func (r *Block) snapshotLocked() *Snapshot {
+	s := &Snapshot{
+		rowCount:   r.rowCount,
+		blockCount: r.blockCount,
+	}
+	if len(r.data) > 0 {
+		last := len(r.data) - 1
+		tail := *r.data[last]
+		s.head = r.data[:last:last] // capped so the snapshot can never see runs appended later
+		s.tail = &tail
+	}
+	return s
+}
+
+// returns the number of rows in the snapshot
+func (r *Snapshot) RowCount() uint {
+	return r.rowCount
+}
+
+// returns the number of run length encoded blocks in the snapshot
+func (r *Snapshot) BlockCount() uint {
+	return r.blockCount
+}
+
+// iterates each row in the snapshot
+func (r *Snapshot) Iterate(f IteratorFn) {
+	r.IterateRuns(func(index uint, length uint, value interface{}) {
+		for row := uint(0); row < length; row++ {
+			// call the iterator function for the Length of the rlBlock
+			f(index+row, value)
+		}
+	})
+}
+
+// iterates each row in the snapshot from the last row to the first
+func (r *Snapshot) IterateReverse(f IteratorFn) {
+	for i := r.runCount() - 1; i >= 0; i-- {
+		b := r.run(i)
+		for row := b.Length; row > 0; row-- {
+			f(b.RowIndex+row-1, b.Value)
+		}
+	}
+}
+
+// iterates each run in the snapshot
+func (r *Snapshot) IterateRuns(f RunIteratorFn) {
+	for _, b := range r.head {
+		f(b.RowIndex, b.Length, b.Value)
+	}
+	if r.tail != nil {
+		f(r.tail.RowIndex, r.tail.Length, r.tail.Value)
+	}
+}
+
+// returns the position of the run containing row, or runCount() if the row is not in the snapshot
+func (r *Snapshot) runIndex(row uint) int {
+	return sort.Search(r.runCount(), func(i int) bool {
+		b := r.run(i)
+		return b.RowIndex+b.Length > row
+	})
+}
+
+// returns the number of runs in the snapshot
+func (r *Snapshot) runCount() int {
+	if r.tail == nil {
+		return 0
+	}
+	return len(r.head) + 1
+}
+
+// returns the run at position i
+func (r *Snapshot) run(i int) *rlBlock {
+	if i == len(r.head) {
+		return r.tail
+	}
+	return r.head[i]
+}
@@ -0,0 +1,111 @@
+package block
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+// collects the values stored in the snapshot in row order
+func snapshotValues(s *Snapshot) []interface{} {
+	result := []interface{}{}
+	s.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestSnapshotIsUnaffectedByAppends(t *testing.T) {
+	list := New(10)
+	list.Append("Value 1")
+	list.Append("Value 2")
+
+	s := list.Snapshot()
+	list.Append("Value 2") // extends the tail run
+	list.Append("Value 3") // adds a new run
+
+	assert.Equal(t, snapshotValues(s), []interface{}{"Value 1", "Value 2"})
+	assert.Equal(t, s.RowCount(), uint(2))
+	assert.Equal(t, s.BlockCount(), uint(2))
+	assert.Equal(t, valuesOf(list), []interface{}{"Value 1", "Value 2", "Value 2", "Value 3"})
+}
+
+func TestSnapshotOfEmptyBlock(t *testing.T) {
+	s := New(0).Snapshot()
+	assert.Equal(t, s.RowCount(), uint(0))
+	assert.Equal(t, len(snapshotValues(s)), 0)
+}
+
+func TestAppendDuringIterate(t *testing.T) {
+	list := New(10)
+	list.Append(1)
+	list.Append(2)
+
+	// the iteration holds no lock, so appending from the iterator function does not deadlock
+	count := 0
+	list.Iterate(func(index uint, value interface{}) {
+		list.Append(value)
+		count++
+	})
+	assert.Equal(t, count, 2, "Expected only the rows present when the iteration started")
+	assert.Equal(t, list.RowCount(), uint(4))
+}
+
+func TestAppendDuringScanRangeAndIterateRunsRange(t *testing.T) {
+	list := New(10)
+	assert.Nil(t, list.EnableZoneMap(1), "Unexpected Error")
+	list.AppendMany(1, 1, 2, 3)
+
+	// like Iterate, neither holds the lock while calling f
+	rows := []uint{}
+	err := list.ScanRange(1, 2, func(index uint, value interface{}) {
+		list.Append(value)
+		rows = append(rows, index)
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, rows, []uint{0, 1, 2}, "Expected only the rows present when the scan started")
+	assert.Equal(t, list.RowCount(), uint(7))
+
+	runs := [][2]uint{}
+	list.IterateRunsRange(1, 5, func(index uint, length uint, value interface{}) {
+		list.Append(value)
+		runs = append(runs, [2]uint{index, length})
+	})
+	assert.Equal(t, runs, [][2]uint{{1, 1}, {2, 1}, {3, 1}, {4, 2}})
+	assert.Equal(t, list.RowCount(), uint(11))
+}
+
+func TestConcurrentSnapshotReadsAndAppends(t *testing.T) {
+	list := New(10)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			list.Append(i / 7)
+		}
+	}()
+
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				s := list.Snapshot()
+				var rows uint
+				s.Iterate(func(index uint, value interface{}) {
+					if index != rows || value.(int) != int(index/7) {
+						t.Error("Unexpected row", index, value)
+					}
+					rows++
+				})
+				if rows != s.RowCount() {
+					t.Error("Expected", s.RowCount(), "rows, got", rows)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,365 @@
+package block
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"iter"
+	"sort"
+
+	"github.com/lummie/golib/column/value"
+)
+
+var ErrNotSorted = errors.New("block: value is less than the last value in the sorted block")
+
+// SortedBlock is a Block whose values are held in ascending order as defined by value.Compare
+// Rows can be located with a binary search over the runs, so lookups are O(log runs). The Block is kept private and
+// only the methods of SortedBlock can change its rows, so every row added is checked against the order.
+type SortedBlock struct {
+	block *Block
+}
+
+// Creates a new empty SortedBlock
+// Capacity is the initial array capacity
+func NewSorted(capacity int) *SortedBlock {
+	return &SortedBlock{block: New(capacity)}
+}
+
+// returns a SortedBlock holding a copy of the rows of an existing Block after checking its values are in ascending order
+// the rows are copied so later changes to b cannot break the order
+// returns ErrNotSorted if they are not, or value.ErrNotComparable if the values cannot be ordered
+func AsSorted(b *Block) (*SortedBlock, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	err := checkSortedLocked(b)
+	if err != nil {
+		return nil, err
+	}
+	result := NewSorted(len(b.data))
+	for _, run := range b.data {
+		// result is not shared until it is returned so the lock is not needed
+		result.block.appendRunLocked(run.Value, run.Length)
+	}
+	return result, nil
+}
+
+// checks the runs of b are in ascending order, the caller must hold the lock
+func checkSortedLocked(b *Block) error {
+	for i := 1; i < len(b.data); i++ {
+		c, err := value.Compare(b.data[i-1].Value, b.data[i].Value)
+		if err != nil {
+			return err
+		}
+		if c > 0 {
+			return ErrNotSorted
+		}
+	}
+	return nil
+}
+
+// appends a row to the SortedBlock, returning the row index of the value
+// returns ErrNotSorted without appending if value is less than the last value
+func (r *SortedBlock) Append(value interface{}) (uint, error) {
+	r.block.Lock()
+	defer r.block.Unlock()
+
+	err := r.checkOrderLocked(value)
+	if err != nil {
+		return 0, err
+	}
+	return r.block.appendRunLocked(value, 1), nil
+}
+
+// appends each value as a row of the SortedBlock, returning the row index of the first value appended, or the number
+// of rows if there are no values
+// the whole batch is checked first, so if a value is out of order ErrNotSorted is returned and no values are appended
+func (r *SortedBlock) AppendMany(values ...interface{}) (uint, error) {
+	r.block.Lock()
+	defer r.block.Unlock()
+
+	for i, v := range values {
+		var err error
+		if i == 0 {
+			err = r.checkOrderLocked(v)
+		} else {
+			err = checkOrder(values[i-1], v)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	first := r.block.rowCount
+	for _, v := range values {
+		r.block.appendRunLocked(v, 1)
+	}
+	return first, nil
+}
+
+// appends count rows all holding value in a single step, returning the row index of the first row appended, or the
+// number of rows if count is 0
+// returns ErrNotSorted without appending if value is less than the last value
+func (r *SortedBlock) AppendRun(value interface{}, count uint) (uint, error) {
+	r.block.Lock()
+	defer r.block.Unlock()
+
+	first := r.block.rowCount
+	if count == 0 {
+		return first, nil
+	}
+	err := r.checkOrderLocked(value)
+	if err != nil {
+		return 0, err
+	}
+	r.block.appendRunLocked(value, count)
+	return first, nil
+}
+
+// checks value can be appended after the last value, the caller must hold the lock
+func (r *SortedBlock) checkOrderLocked(v interface{}) error {
+	if len(r.block.data) == 0 {
+		return nil
+	}
+	return checkOrder(r.block.data[len(r.block.data)-1].Value, v)
+}
+
+// returns ErrNotSorted if next is less than previous
+func checkOrder(previous interface{}, next interface{}) error {
+	c, err := value.Compare(previous, next)
+	if err != nil {
+		return err
+	}
+	if c > 0 {
+		return ErrNotSorted
+	}
+	return nil
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	READING
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// returns the number of rows stored in the SortedBlock
+func (r *SortedBlock) RowCount() uint {
+	return r.block.RowCount()
+}
+
+// returns the number of run length encoded blocks stored in the SortedBlock
+func (r *SortedBlock) BlockCount() uint {
+	return r.block.BlockCount()
+}
+
+// iterates each row in ascending order
+func (r *SortedBlock) Iterate(f IteratorFn) {
+	r.block.Iterate(f)
+}
+
+// iterates each row in descending order
+func (r *SortedBlock) IterateReverse(f IteratorFn) {
+	r.block.IterateReverse(f)
+}
+
+// iterates each run in ascending order
+func (r *SortedBlock) IterateRuns(f RunIteratorFn) {
+	r.block.IterateRuns(f)
+}
+
+// returns a sequence of the rows in ascending order
+func (r *SortedBlock) All() iter.Seq2[uint, interface{}] {
+	return r.block.All()
+}
+
+// returns a sequence of the runs in ascending order
+func (r *SortedBlock) Runs() iter.Seq[Run] {
+	return r.block.Runs()
+}
+
+// returns an immutable view of the rows currently stored in the SortedBlock
+func (r *SortedBlock) Snapshot() *Snapshot {
+	return r.block.Snapshot()
+}
+
+// returns a Cursor over a snapshot of the rows currently stored in the SortedBlock
+func (r *SortedBlock) Cursor() *Cursor {
+	return r.block.Cursor()
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	PERSISTENCE
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// writes the SortedBlock to a writer in the same RLEARRAY format as a Block
+func (r *SortedBlock) Write(writer io.Writer) error {
+	return r.block.Write(writer)
+}
+
+// reads the SortedBlock from a Reader written by Block.Write or SortedBlock.Write, overwriting the current contents
+// the stream is decoded and its order checked before it is swapped in, so if an error occurs, including ErrNotSorted
+// when the rows are not in ascending order, the current contents are left unchanged
+func (r *SortedBlock) Read(reader io.Reader) error {
+	decoded := New(0)
+	err := decoded.Read(reader)
+	if err != nil {
+		return err
+	}
+	// decoded is not shared so the lock is not needed
+	err = checkSortedLocked(decoded)
+	if err != nil {
+		return err
+	}
+
+	r.block.Lock()
+	defer r.block.Unlock()
+	r.block.replaceLocked(decoded)
+	return nil
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	SEARCHING
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// returns the first row holding a value that is not less than v, or the row count if there is none
+func (r *SortedBlock) LowerBound(v interface{}) (uint, error) {
+	r.block.RLock()
+	defer r.block.RUnlock()
+	return r.searchLocked(v, func(c int) bool { return c >= 0 })
+}
+
+// returns the first row holding a value greater than v, or the row count if there is none
+func (r *SortedBlock) UpperBound(v interface{}) (uint, error) {
+	r.block.RLock()
+	defer r.block.RUnlock()
+	return r.searchLocked(v, func(c int) bool { return c > 0 })
+}
+
+// returns the range of rows holding a value equal to v as the first row and the number of rows
+// if no rows hold v the length is 0 and index is the row where v would be inserted
+func (r *SortedBlock) EqualRange(v interface{}) (uint, uint, error) {
+	r.block.RLock()
+	defer r.block.RUnlock()
+
+	lower, err := r.searchLocked(v, func(c int) bool { return c >= 0 })
+	if err != nil {
+		return 0, 0, err
+	}
+	upper, err := r.searchLocked(v, func(c int) bool { return c > 0 })
+	if err != nil {
+		return 0, 0, err
+	}
+	return lower, upper - lower, nil
+}
+
+// binary searches the runs for the first whose value compared to v satisfies found, returning its starting row
+func (r *SortedBlock) searchLocked(v interface{}, found func(c int) bool) (uint, error) {
+	var compareErr error
+	i := sort.Search(len(r.block.data), func(i int) bool {
+		c, err := value.Compare(r.block.data[i].Value, v)
+		if err != nil {
+			compareErr = err
+			return true
+		}
+		return found(c)
+	})
+	if compareErr != nil {
+		return 0, compareErr
+	}
+	if i == len(r.block.data) {
+		return r.block.rowCount, nil
+	}
+	return r.block.data[i].RowIndex, nil
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	MERGING
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// merges sorted blocks into a new SortedBlock holding every row of the inputs in ascending order
+// the merge works run by run, adjacent runs of equal values from different inputs are combined into a single run
+func Merge(blocks ...*SortedBlock) (*SortedBlock, error) {
+	h := &mergeHeap{}
+	capacity := 0
+	for i, b := range blocks {
+		var runs []rlBlock
+		b.IterateRuns(func(index uint, length uint, value interface{}) {
+			runs = append(runs, rlBlock{RowIndex: index, Length: length, Value: value})
+		})
+		if len(runs) > 0 {
+			h.cursors = append(h.cursors, &mergeCursor{runs: runs, input: i})
+			capacity += len(runs)
+		}
+	}
+	heap.Init(h)
+
+	result := NewSorted(capacity)
+	for h.Len() > 0 {
+		if h.err != nil {
+			return nil, h.err
+		}
+		cursor := h.cursors[0]
+		run := cursor.runs[cursor.pos]
+		// result is not shared until it is returned so the lock is not needed
+		result.block.appendRunLocked(run.Value, run.Length)
+
+		cursor.pos++
+		if cursor.pos == len(cursor.runs) {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+	return result, nil
+}
+
+// the position within the runs of one of the blocks being merged
+type mergeCursor struct {
+	runs  []rlBlock
+	pos   int
+	input int // position of the block in the arguments to Merge
+}
+
+// a min heap of cursors ordered by the value of their current run, ties are broken by input order to keep the merge stable
+type mergeHeap struct {
+	cursors []*mergeCursor
+	err     error // first error comparing values
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.cursors)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	c, err := value.Compare(h.cursors[i].runs[h.cursors[i].pos].Value, h.cursors[j].runs[h.cursors[j].pos].Value)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	if c == 0 {
+		return h.cursors[i].input < h.cursors[j].input
+	}
+	return c < 0
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.cursors = append(h.cursors, x.(*mergeCursor))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
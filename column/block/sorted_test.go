@@ -0,0 +1,168 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/value"
+)
+
+// creates a sorted block holding the values in order
+func sortedOf(t *testing.T, values ...interface{}) *SortedBlock {
+	list := NewSorted(len(values))
+	for _, v := range values {
+		_, err := list.Append(v)
+		assert.Nil(t, err, "Unexpected Append Error")
+	}
+	return list
+}
+
+// collects the values stored in the block in row order
+func valuesOf(list *Block) []interface{} {
+	result := []interface{}{}
+	list.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestSortedAppendRejectsOutOfOrderValues(t *testing.T) {
+	list := sortedOf(t, 1, 2, 2, 5)
+
+	_, err := list.Append(4)
+	assert.Equal(t, err, ErrNotSorted)
+	assert.Equal(t, list.RowCount(), uint(4), "Expected the rejected value not to be appended")
+
+	_, err = list.Append("6")
+	assert.Equal(t, err, value.ErrNotComparable)
+
+	index, err := list.Append(5)
+	assert.Nil(t, err, "Unexpected Append Error")
+	assert.Equal(t, index, uint(4))
+}
+
+func TestSortedAppendManyRejectsOutOfOrderValues(t *testing.T) {
+	list := sortedOf(t, 1, 2)
+
+	_, err := list.AppendMany(3, 5, 4)
+	assert.Equal(t, err, ErrNotSorted)
+	_, err = list.AppendMany(0)
+	assert.Equal(t, err, ErrNotSorted)
+	_, err = list.AppendMany("3")
+	assert.Equal(t, err, value.ErrNotComparable)
+	assert.Equal(t, valuesOf(list.block), []interface{}{1, 2}, "Expected the rejected values not to be appended")
+
+	index, err := list.AppendMany(2, 3, 3)
+	assert.Nil(t, err, "Unexpected Append Error")
+	assert.Equal(t, index, uint(2))
+
+	row, _ := list.LowerBound(3)
+	assert.Equal(t, row, uint(3))
+}
+
+func TestSortedAppendRunRejectsOutOfOrderValues(t *testing.T) {
+	list := sortedOf(t, 1, 2)
+
+	_, err := list.AppendRun(1, 3)
+	assert.Equal(t, err, ErrNotSorted)
+	_, err = list.AppendRun("3", 3)
+	assert.Equal(t, err, value.ErrNotComparable)
+	assert.Equal(t, valuesOf(list.block), []interface{}{1, 2}, "Expected the rejected runs not to be appended")
+
+	index, err := list.AppendRun(5, 3)
+	assert.Nil(t, err, "Unexpected Append Error")
+	assert.Equal(t, index, uint(2))
+	index, err = list.AppendRun(0, 0)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, index, uint(5), "Expected the row count when count is 0")
+
+	row, _ := list.LowerBound(3)
+	assert.Equal(t, row, uint(2))
+}
+
+func TestSortedRead(t *testing.T) {
+	list := sortedOf(t, 1, 2)
+
+	unsorted := New(0)
+	unsorted.AppendMany(3, 1)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, unsorted.Write(buf), "Unexpected Write Error")
+	err := list.Read(buf)
+	assert.Equal(t, err, ErrNotSorted)
+	assert.Equal(t, valuesOf(list.block), []interface{}{1, 2}, "Expected the rows to be unchanged")
+
+	buf.Reset()
+	assert.Nil(t, sortedOf(t, 4, 4, 7).Write(buf), "Unexpected Write Error")
+	err = list.Read(buf)
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, valuesOf(list.block), []interface{}{4, 4, 7})
+	_, err = list.Append(5)
+	assert.Equal(t, err, ErrNotSorted, "Expected the order to be checked against the rows read")
+}
+
+func TestAsSorted(t *testing.T) {
+	list := New(10)
+	list.Append(1)
+	list.Append(3)
+
+	sorted, err := AsSorted(list)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, valuesOf(sorted.block), []interface{}{1, 3})
+
+	// the rows are copied so appending to the Block does not change the SortedBlock
+	list.Append(2)
+	assert.Equal(t, sorted.RowCount(), uint(2))
+	_, err = AsSorted(list)
+	assert.Equal(t, err, ErrNotSorted)
+}
+
+func TestLowerUpperBoundAndEqualRange(t *testing.T) {
+	list := sortedOf(t, 1, 1, 3, 3, 3, 7, 9, 9)
+
+	row, err := list.LowerBound(3)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, row, uint(2))
+
+	row, _ = list.LowerBound(4)
+	assert.Equal(t, row, uint(5))
+
+	row, _ = list.LowerBound(10)
+	assert.Equal(t, row, uint(8), "Expected the row count when all values are less")
+
+	row, _ = list.UpperBound(3)
+	assert.Equal(t, row, uint(5))
+
+	index, length, err := list.EqualRange(3)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, index, uint(2))
+	assert.Equal(t, length, uint(3))
+
+	index, length, _ = list.EqualRange(0)
+	assert.Equal(t, index, uint(0))
+	assert.Equal(t, length, uint(0))
+
+	_, _, err = list.EqualRange("3")
+	assert.Equal(t, err, value.ErrNotComparable)
+}
+
+func TestMerge(t *testing.T) {
+	a := sortedOf(t, 1, 1, 4, 8)
+	b := sortedOf(t, 0, 1, 5, 5, 9)
+	c := sortedOf(t)
+	d := sortedOf(t, 8, 8)
+
+	merged, err := Merge(a, b, c, d)
+	assert.Nil(t, err, "Unexpected Merge Error")
+	assert.Equal(t, valuesOf(merged.block), []interface{}{0, 1, 1, 1, 4, 5, 5, 8, 8, 8, 9})
+	assert.Equal(t, merged.BlockCount(), uint(6), "Expected equal runs from different inputs to be combined")
+
+	index, length, _ := merged.EqualRange(8)
+	assert.Equal(t, index, uint(7))
+	assert.Equal(t, length, uint(3))
+}
+
+func TestMergeNotComparable(t *testing.T) {
+	_, err := Merge(sortedOf(t, 1), sortedOf(t, "a"))
+	assert.Equal(t, err, value.ErrNotComparable)
+}
@@ -0,0 +1,207 @@
+package block
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lummie/golib/column/value"
+)
+
+var ErrInvalidZoneSize = errors.New("block: runs per zone must be at least 1")
+
+// Zone summarises the values held in a group of consecutive runs of a Block
+type Zone struct {
+	FirstRow uint        // first row in the zone
+	Rows     uint        // number of rows in the zone
+	Min      interface{} // smallest non nil value in the zone, nil if there are none
+	Max      interface{} // largest non nil value in the zone, nil if there are none
+	Mixed    bool        // true if the zone holds values that cannot be compared, Min and Max then only cover some values
+}
+
+// a zone map splits the runs of a Block into zones of runsPerZone runs, keeping the min and max of each
+type zoneMap struct {
+	runsPerZone int
+	zones       []*zoneSummary
+}
+
+type zoneSummary struct {
+	firstRun  int         // position in data of the first run in the zone
+	min       interface{} // smallest non nil value
+	max       interface{} // largest non nil value
+	unordered bool        // true if the zone holds values that cannot be compared, such zones are never skipped
+}
+
+// enables a zone map for the Block, summarising the min and max values of every runsPerZone runs
+// once enabled the zone map is maintained on every append and used by ScanRange to skip zones
+func (r *Block) EnableZoneMap(runsPerZone int) error {
+	if runsPerZone < 1 {
+		return ErrInvalidZoneSize
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.zones = &zoneMap{runsPerZone: runsPerZone}
+	r.rebuildZoneMapLocked()
+	return nil
+}
+
+// returns the zones of the zone map, or nil if it has not been enabled
+func (r *Block) Zones() []Zone {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.zones == nil {
+		return nil
+	}
+	result := make([]Zone, len(r.zones.zones))
+	for i, z := range r.zones.zones {
+		first, rows := r.zoneRowsLocked(i)
+		result[i] = Zone{FirstRow: first, Rows: rows, Min: z.min, Max: z.max, Mixed: z.unordered}
+	}
+	return result
+}
+
+// calls f for every row holding a value v where min <= v <= max, in row order
+// a nil bound leaves that end of the range open, nil values are never in range
+// zones whose min and max show they cannot hold a value in range are skipped, as are runs outside the range
+// returns value.ErrNotComparable if a value in a scanned zone cannot be compared with the bounds
+func (r *Block) ScanRange(min interface{}, max interface{}, f IteratorFn) error {
+	return r.scanRange(context.Background(), min, max, f)
+}
+
+// scans the rows in range, checking the context between runs and every cancelCheckInterval rows, see ScanRange
+// the runs are read from a Snapshot and the zones copied with it, so the lock is not held while f is called
+func (r *Block) scanRange(ctx context.Context, min interface{}, max interface{}, f IteratorFn) error {
+	r.RLock()
+	s := r.snapshotLocked()
+	// without a zone map every run is a candidate
+	zones := []zoneSummary{{firstRun: 0, unordered: true}}
+	if r.zones != nil {
+		// the last zone is updated in place by appends, so the summaries are copied
+		zones = make([]zoneSummary, len(r.zones.zones))
+		for i, z := range r.zones.zones {
+			zones[i] = *z
+		}
+	}
+	r.RUnlock()
+
+	for i, z := range zones {
+		if !z.unordered {
+			overlaps, err := overlapsRange(z.min, z.max, min, max)
+			if err != nil {
+				return err
+			}
+			if !overlaps {
+				continue
+			}
+		}
+
+		end := s.runCount()
+		if i+1 < len(zones) {
+			end = zones[i+1].firstRun
+		}
+		for run := z.firstRun; run < end; run++ {
+			b := s.run(run)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			in, err := inRange(b.Value, min, max)
+			if err != nil {
+				return err
+			}
+			if !in {
+				continue
+			}
+			for row := uint(0); row < b.Length; row++ {
+				if row > 0 && row%cancelCheckInterval == 0 {
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+				}
+				f(b.RowIndex+row, b.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// returns true if v is within the bounds
+func inRange(v interface{}, min interface{}, max interface{}) (bool, error) {
+	if v == nil {
+		return false, nil
+	}
+	return overlapsRange(v, v, min, max)
+}
+
+// returns true if the range low to high overlaps the bounds
+func overlapsRange(low interface{}, high interface{}, min interface{}, max interface{}) (bool, error) {
+	if low == nil {
+		// a zone holding only nil values
+		return false, nil
+	}
+	if min != nil {
+		c, err := value.Compare(high, min)
+		if err != nil || c < 0 {
+			return false, err
+		}
+	}
+	if max != nil {
+		c, err := value.Compare(low, max)
+		if err != nil || c > 0 {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// returns the first row and number of rows in zone i, the caller must hold the lock
+func (r *Block) zoneRowsLocked(i int) (uint, uint) {
+	zones := r.zones.zones
+	first := r.data[zones[i].firstRun].RowIndex
+	if i+1 < len(zones) {
+		return first, r.data[zones[i+1].firstRun].RowIndex - first
+	}
+	return first, r.rowCount - first
+}
+
+// summarises every run stored in the Block, the caller must hold the write lock and the zone map must be enabled
+func (r *Block) rebuildZoneMapLocked() {
+	r.zones.zones = nil
+	for i, b := range r.data {
+		r.zones.addRun(i, b.Value)
+	}
+}
+
+// adds the run at position run in data to the zone map, starting a new zone when the last is full
+func (r *zoneMap) addRun(run int, v interface{}) {
+	if len(r.zones) == 0 || run-r.zones[len(r.zones)-1].firstRun >= r.runsPerZone {
+		r.zones = append(r.zones, &zoneSummary{firstRun: run})
+	}
+	z := r.zones[len(r.zones)-1]
+
+	if v == nil || z.unordered {
+		return
+	}
+	if z.min == nil {
+		z.min = v
+		z.max = v
+		return
+	}
+
+	c, err := value.Compare(v, z.min)
+	if err != nil {
+		z.unordered = true
+		return
+	}
+	if c < 0 {
+		z.min = v
+	}
+	c, err = value.Compare(v, z.max)
+	if err != nil {
+		z.unordered = true
+		return
+	}
+	if c > 0 {
+		z.max = v
+	}
+}
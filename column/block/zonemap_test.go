@@ -0,0 +1,98 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/value"
+)
+
+// collects the rows reported by ScanRange
+func scanRows(t *testing.T, list *Block, min interface{}, max interface{}) []uint {
+	rows := []uint{}
+	err := list.ScanRange(min, max, func(index uint, value interface{}) {
+		rows = append(rows, index)
+	})
+	assert.Nil(t, err, "Unexpected ScanRange Error")
+	return rows
+}
+
+func TestZoneMapMaintainedOnAppend(t *testing.T) {
+	list := New(10)
+	assert.Nil(t, list.EnableZoneMap(2))
+	for _, v := range []interface{}{5, 5, 3, 8, 8, 1, nil, 9} {
+		list.Append(v)
+	}
+
+	assert.Equal(t, list.Zones(), []Zone{
+		{FirstRow: 0, Rows: 3, Min: 3, Max: 5},
+		{FirstRow: 3, Rows: 3, Min: 1, Max: 8},
+		{FirstRow: 6, Rows: 2, Min: 9, Max: 9},
+	})
+}
+
+func TestZoneMapEnabledOnExistingRows(t *testing.T) {
+	list := New(10)
+	assert.Nil(t, list.Zones(), "Expected no zones before the zone map is enabled")
+	list.Append(1)
+	list.Append(2)
+	list.Append(3)
+
+	assert.Equal(t, list.EnableZoneMap(0), ErrInvalidZoneSize)
+	assert.Nil(t, list.EnableZoneMap(2))
+	assert.Equal(t, len(list.Zones()), 2)
+}
+
+func TestScanRange(t *testing.T) {
+	list := New(100)
+	list.EnableZoneMap(3)
+	// roughly ordered values, like timestamps
+	for i := 0; i < 100; i++ {
+		list.Append(i / 4)
+	}
+
+	assert.Equal(t, scanRows(t, list, 10, 11), []uint{40, 41, 42, 43, 44, 45, 46, 47})
+	assert.Equal(t, scanRows(t, list, 24, nil), []uint{96, 97, 98, 99}, "Expected a nil max to be open")
+	assert.Equal(t, len(scanRows(t, list, nil, 1)), 8, "Expected a nil min to be open")
+	assert.Equal(t, len(scanRows(t, list, 30, 40)), 0)
+}
+
+func TestScanRangeWithoutZoneMap(t *testing.T) {
+	list := New(10)
+	list.Append(3)
+	list.Append(nil)
+	list.Append(1)
+	list.Append(2)
+
+	assert.Equal(t, scanRows(t, list, 2, 3), []uint{0, 3})
+	assert.Equal(t, scanRows(t, list, nil, nil), []uint{0, 2, 3}, "Expected nil values never to be in range")
+}
+
+func TestScanRangeNotComparable(t *testing.T) {
+	list := New(10)
+	list.EnableZoneMap(2)
+	list.Append(1)
+	list.Append(2)
+	list.Append("a")
+	list.Append(3)
+
+	// the second zone holds values that cannot be compared so it is always scanned
+	assert.Equal(t, list.Zones()[1].Mixed, true)
+	err := list.ScanRange(nil, 2, func(index uint, value interface{}) {})
+	assert.Equal(t, err, value.ErrNotComparable)
+}
+
+func TestZoneMapRebuiltOnRead(t *testing.T) {
+	source := New(10)
+	source.Append(7)
+	source.Append(9)
+	buf := new(bytes.Buffer)
+	source.Write(buf)
+
+	list := New(10)
+	list.EnableZoneMap(10)
+	list.Append(1)
+	assert.Nil(t, list.Read(buf), "Unexpected Read Error")
+	assert.Equal(t, list.Zones(), []Zone{{FirstRow: 0, Rows: 2, Min: 7, Max: 9}})
+}
@@ -0,0 +1,261 @@
+package bloom
+
+// Implements a Bloom filter over the interface{} values stored in columns
+// A Bloom filter answers "definitely not present" or "may be present" for a value using a small fixed size bit set,
+// allowing lookups to skip data that cannot contain a value without loading it.
+// Values are hashed from their type and contents so a persisted filter gives the same answers in another process.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+)
+
+var ErrInvalidFilter = errors.New("bloom: invalid filter")
+
+type Filter struct {
+	sync.RWMutex
+	bits []uint64 // the bit set
+	m    uint     // number of bits
+	k    uint     // number of hash functions
+}
+
+// Creates a new Filter with m bits and k hash functions
+func New(m uint, k uint) *Filter {
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	}
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Creates a new Filter sized to hold n values with a false positive rate of about fpRate
+func NewWithEstimates(n uint, fpRate float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	return New(uint(m), uint(k))
+}
+
+// adds a value to the filter
+func (r *Filter) Add(value interface{}) {
+	h1, h2 := hash(value)
+	r.Lock()
+	defer r.Unlock()
+	for i := uint(0); i < r.k; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(r.m)
+		r.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// returns false if the value has definitely not been added to the filter, true if it may have been
+func (r *Filter) MayContain(value interface{}) bool {
+	h1, h2 := hash(value)
+	r.RLock()
+	defer r.RUnlock()
+	for i := uint(0); i < r.k; i++ {
+		bit := (h1 + uint64(i)*h2) % uint64(r.m)
+		if r.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the number of bits and hash functions used by the filter
+func (r *Filter) Size() (uint, uint) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.m, r.k
+}
+
+// returns two 64 bit hashes of the value, combined to produce the k bit positions
+func hash(value interface{}) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(encode(value))
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1 // odd so the positions cycle through every bit
+	return h1, h2
+}
+
+// encodes the value with its type so values that are not == do not share an encoding, and values that are == do
+func encode(value interface{}) []byte {
+	buf := make([]byte, 9)
+	switch v := value.(type) {
+	case nil:
+		return []byte{0}
+	case string:
+		return append([]byte{1}, v...)
+	case int:
+		buf[0] = 2
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+	case int64:
+		buf[0] = 3
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+	case int32:
+		buf[0] = 4
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+	case uint:
+		buf[0] = 5
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+	case uint64:
+		buf[0] = 6
+		binary.BigEndian.PutUint64(buf[1:], v)
+	case uint32:
+		buf[0] = 7
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+	case float64:
+		if v == 0 {
+			v = 0 // -0 == 0 so both are encoded as 0
+		}
+		buf[0] = 8
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+	case bool:
+		if v {
+			return []byte{9, 1}
+		}
+		return []byte{9, 0}
+	default:
+		v = positiveZero(v)
+		return append([]byte{255}, fmt.Sprintf("%T:%#v", v, v)...)
+	}
+	return buf
+}
+
+// returns the value with any negative zero float replaced by positive zero, as the two are == but format differently
+// covers float32, complex values and named float types that are encoded by their formatted value
+func positiveZero(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if v.Float() == 0 {
+			return reflect.Zero(v.Type()).Interface()
+		}
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		re, im := real(c), imag(c)
+		if re == 0 {
+			re = 0
+		}
+		if im == 0 {
+			im = 0
+		}
+		result := reflect.New(v.Type()).Elem()
+		result.SetComplex(complex(re, im))
+		return result.Interface()
+	}
+	return value
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	PERSISTENCE
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// Encodes the Filter in GOB format to a byte array
+func (r *Filter) GobEncode() ([]byte, error) {
+	r.RLock()
+	defer r.RUnlock()
+	buf := new(bytes.Buffer)
+	encoder := gob.NewEncoder(buf)
+
+	err := encoder.Encode(r.m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Encode(r.k)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Encode(r.bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decodes the byte array in GOB format to the Filter
+func (r *Filter) GobDecode(buf []byte) error {
+	decoder := gob.NewDecoder(bytes.NewBuffer(buf))
+
+	var m, k uint
+	err := decoder.Decode(&m)
+	if err != nil {
+		return err
+	}
+
+	err = decoder.Decode(&k)
+	if err != nil {
+		return err
+	}
+
+	var bits []uint64
+	err = decoder.Decode(&bits)
+	if err != nil {
+		return err
+	}
+
+	if m == 0 || k == 0 || uint(len(bits)) != (m+63)/64 {
+		return ErrInvalidFilter
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.m = m
+	r.k = k
+	r.bits = bits
+	return nil
+}
+
+// writes the filter to a writer
+func (r *Filter) Write(writer io.Writer) error {
+	enc := gob.NewEncoder(writer)
+
+	err := enc.Encode("RLEBLOOM")
+	if err != nil {
+		return err
+	}
+
+	return enc.Encode(r)
+}
+
+// reads the filter from a Reader, overwriting the current contents
+func (r *Filter) Read(reader io.Reader) error {
+	dec := gob.NewDecoder(reader)
+
+	// check we are decoding the correct type
+	var typeCheck string
+	err := dec.Decode(&typeCheck)
+	if err != nil {
+		return err
+	}
+
+	if typeCheck != "RLEBLOOM" {
+		return errors.New("Tried to load a stream that is not RLEBLOOM")
+	}
+
+	return dec.Decode(r)
+}
@@ -0,0 +1,102 @@
+package bloom
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestAddedValuesAreFound(t *testing.T) {
+	filter := NewWithEstimates(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.Add("Item " + strconv.Itoa(i))
+		filter.Add(i)
+	}
+	filter.Add(nil)
+	filter.Add(true)
+	filter.Add(2.5)
+
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, filter.MayContain("Item "+strconv.Itoa(i)), true, "Expected no false negatives")
+		assert.Equal(t, filter.MayContain(i), true, "Expected no false negatives")
+	}
+	assert.Equal(t, filter.MayContain(nil), true)
+	assert.Equal(t, filter.MayContain(true), true)
+	assert.Equal(t, filter.MayContain(2.5), true)
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	filter := NewWithEstimates(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.Add(i)
+	}
+
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if filter.MayContain(i) {
+			falsePositives++
+		}
+	}
+	// allow for some variance around the 1% target
+	if falsePositives > 300 {
+		t.Error("Too many false positives", falsePositives)
+	}
+}
+
+func TestValuesOfDifferentTypesAreDistinct(t *testing.T) {
+	filter := New(1024, 3)
+	filter.Add(1)
+	assert.Equal(t, filter.MayContain(1), true)
+	assert.Equal(t, filter.MayContain("1"), false)
+	assert.Equal(t, filter.MayContain(int64(1)), false)
+}
+
+// a named float type, encoded by its formatted value
+type celsius float64
+
+func TestNegativeZeroMatchesZero(t *testing.T) {
+	negative := math.Copysign(0, -1)
+	values := []interface{}{0.0, float32(0), complex(0, 0), celsius(0)}
+	negatives := []interface{}{negative, float32(negative), complex(negative, negative), celsius(negative)}
+	for i := range values {
+		filter := New(1024, 3)
+		filter.Add(values[i])
+		assert.Equal(t, filter.MayContain(negatives[i]), true, "Expected -0 to match 0", values[i])
+
+		filter = New(1024, 3)
+		filter.Add(negatives[i])
+		assert.Equal(t, filter.MayContain(values[i]), true, "Expected 0 to match -0", values[i])
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	filter := NewWithEstimates(100, 0.01)
+	for i := 0; i < 100; i++ {
+		filter.Add(i)
+	}
+
+	buf := new(bytes.Buffer)
+	err := filter.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	loaded := New(1, 1)
+	err = loaded.Read(buf)
+	assert.Nil(t, err, "Unexpected Read Error")
+
+	m, k := filter.Size()
+	lm, lk := loaded.Size()
+	assert.Equal(t, lm, m)
+	assert.Equal(t, lk, k)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, loaded.MayContain(i), true, "Expected no false negatives after loading")
+	}
+}
+
+func TestReadInvalidData(t *testing.T) {
+	filter := New(1, 1)
+	err := filter.Read(bytes.NewBufferString("Invalid Data"))
+	assert.NotNil(t, err, "Expected an error reading invalid data")
+}
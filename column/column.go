@@ -0,0 +1,59 @@
+// Package column defines the behaviour shared by the run length encoded column implementations,
+// rlelist.RleList (backed by a linked list) and block.Block (backed by a slice), so either can be used where a
+// column is needed. The conformance tests in package columntest are run against both.
+package column
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// iterator function type declaration, called for each row with the row index and value
+type IteratorFn func(index uint, value interface{})
+
+// run iterator function type declaration, called once per run with the starting row, the number of rows and the value
+type RunIteratorFn func(index uint, length uint, value interface{})
+
+// Run is a value repeated over a contiguous range of rows
+type Run struct {
+	Index  uint        // first row of the run
+	Length uint        // number of rows in the run
+	Value  interface{} // value stored in each row of the run
+}
+
+// Column is a run length encoded column of values
+// consecutive equal values are stored once as a run, with a count of the rows they cover
+type Column interface {
+	// appends a row, returning its row index
+	Append(value interface{}) uint
+	// appends each value as a row, returning the row index of the first value or the number of rows if there are none
+	AppendMany(values ...interface{}) uint
+	// appends count rows holding value, returning the row index of the first or the number of rows if count is 0
+	AppendRun(value interface{}, count uint) uint
+
+	// returns the number of rows stored
+	RowCount() uint
+	// returns the number of runs stored
+	BlockCount() uint
+
+	// calls f for each row in row order
+	Iterate(f IteratorFn)
+	// calls f for each row from the last row to the first
+	IterateReverse(f IteratorFn)
+	// calls f for each run in row order
+	IterateRuns(f RunIteratorFn)
+	// calls f for each row in row order, returning ctx.Err() if the context is cancelled first
+	IterateContext(ctx context.Context, f IteratorFn) error
+	// returns a sequence of the row index and value of each row
+	All() iter.Seq2[uint, interface{}]
+	// returns a sequence of the runs
+	Runs() iter.Seq[Run]
+
+	// writes the column to a writer in the format of the implementation
+	Write(writer io.Writer) error
+	// reads a column written by the same implementation, replacing the current contents
+	Read(reader io.Reader) error
+	// like Read, returning ctx.Err() if the context is cancelled first
+	ReadContext(ctx context.Context, reader io.Reader) error
+}
@@ -0,0 +1,52 @@
+// Package columnfile reads persisted columns whatever their format, detecting the format from the type tag
+package columnfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/rlelist"
+)
+
+// reads the column held in the stream, returning it along with the format it was stored in
+// RLELIST streams are read into an *rlelist.RleList, RLEARRAY and RLECOLUMN streams into a *block.Block
+// returns column.ErrUnknownFormat if the stream is not in a known format
+func Read(reader io.Reader) (column.Column, column.Format, error) {
+	br, ok := reader.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(reader)
+	}
+
+	format, err := column.DetectFormat(br)
+	if err != nil {
+		return nil, format, err
+	}
+
+	switch format {
+	case column.FormatRleList:
+		list := rlelist.New()
+		err = list.Read(br)
+		return list, format, err
+	case column.FormatBlock:
+		b := block.New(0)
+		err = b.Read(br)
+		return b, format, err
+	default:
+		b := block.New(0)
+		err = column.Read(br, b)
+		return b, format, err
+	}
+}
+
+// reads the column held in the file at path, see Read
+func ReadFile(path string) (column.Column, column.Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, column.FormatUnknown, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,60 @@
+package columnfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/rlelist"
+)
+
+func TestRead(t *testing.T) {
+	b := block.New(10)
+	b.AppendMany("a", "a", 1)
+	list := rlelist.FromBlock(b)
+
+	tests := []struct {
+		write    func(buf *bytes.Buffer) error
+		expected column.Format
+	}{
+		{func(buf *bytes.Buffer) error { return list.Write(buf) }, column.FormatRleList},
+		{func(buf *bytes.Buffer) error { return b.Write(buf) }, column.FormatBlock},
+		{func(buf *bytes.Buffer) error { return column.Write(buf, b) }, column.FormatColumn},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		assert.Nil(t, test.write(buf), "Unexpected Write Error")
+
+		c, format, err := Read(buf)
+		assert.Nil(t, err, "Unexpected Read Error")
+		assert.Equal(t, format, test.expected)
+		assert.Equal(t, c.RowCount(), uint(3))
+		assert.Equal(t, c.BlockCount(), uint(2))
+	}
+
+	_, format, err := Read(bytes.NewBufferString("Invalid Data"))
+	assert.Equal(t, err, column.ErrUnknownFormat)
+	assert.Equal(t, format, column.FormatUnknown)
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "column.dat")
+	f, err := os.Create(path)
+	assert.Nil(t, err, "Unexpected Error")
+	list := rlelist.New()
+	list.AppendRun("a", 10)
+	assert.Nil(t, list.Write(f), "Unexpected Write Error")
+	f.Close()
+
+	c, format, err := ReadFile(path)
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, format, column.FormatRleList)
+	assert.Equal(t, c.RowCount(), uint(10))
+
+	_, _, err = ReadFile(filepath.Join(t.TempDir(), "missing.dat"))
+	assert.NotNil(t, err, "Expected an error reading a missing file")
+}
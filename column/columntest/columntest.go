@@ -0,0 +1,227 @@
+// Package columntest provides a conformance test suite for implementations of column.Column
+// Each implementation runs the suite from its own tests, so every implementation is held to the same behaviour:
+//
+//	func TestColumnConformance(t *testing.T) {
+//		columntest.Run(t, func() column.Column { return New() })
+//	}
+package columntest
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+)
+
+// NewFn returns a new empty column of the implementation under test
+type NewFn func() column.Column
+
+// runs the conformance tests against the columns returned by newColumn
+func Run(t *testing.T, newColumn NewFn) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, newColumn NewFn)
+	}{
+		{"Empty", testEmpty},
+		{"Append", testAppend},
+		{"AppendManyAndRun", testAppendManyAndRun},
+		{"IterationsAgree", testIterationsAgree},
+		{"Break", testBreak},
+		{"IterateContextCancelled", testIterateContextCancelled},
+		{"WriteRead", testWriteRead},
+		{"ReadContextCancelled", testReadContextCancelled},
+		{"ConcurrentAppend", testConcurrentAppend},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newColumn)
+		})
+	}
+}
+
+// collects the values of each row in row order
+func values(c column.Column) []interface{} {
+	result := []interface{}{}
+	c.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+// collects the runs in row order
+func runs(c column.Column) []column.Run {
+	result := []column.Run{}
+	c.IterateRuns(func(index uint, length uint, value interface{}) {
+		result = append(result, column.Run{Index: index, Length: length, Value: value})
+	})
+	return result
+}
+
+// a column holding the rows "a", "a", "b", 1, 1, 1, nil, "a"
+func populated(newColumn NewFn) column.Column {
+	c := newColumn()
+	c.AppendMany("a", "a", "b")
+	c.AppendRun(1, 3)
+	c.Append(nil)
+	c.Append("a")
+	return c
+}
+
+func testEmpty(t *testing.T, newColumn NewFn) {
+	c := newColumn()
+	assert.Equal(t, c.RowCount(), uint(0))
+	assert.Equal(t, c.BlockCount(), uint(0))
+	assert.Equal(t, len(values(c)), 0)
+	assert.Equal(t, len(runs(c)), 0)
+	for range c.All() {
+		t.Error("Expected no rows in an empty column")
+	}
+}
+
+func testAppend(t *testing.T, newColumn NewFn) {
+	c := newColumn()
+	assert.Equal(t, c.Append("Value 1"), uint(0))
+	assert.Equal(t, c.Append("Value 1"), uint(1))
+	assert.Equal(t, c.Append("Value 2"), uint(2))
+	assert.Equal(t, c.Append(nil), uint(3))
+	assert.Equal(t, c.Append(nil), uint(4))
+	assert.Equal(t, c.RowCount(), uint(5))
+	assert.Equal(t, c.BlockCount(), uint(3), "Expected equal consecutive values to share a run")
+}
+
+func testAppendManyAndRun(t *testing.T, newColumn NewFn) {
+	c := newColumn()
+	assert.Equal(t, c.AppendMany(), uint(0))
+	assert.Equal(t, c.AppendMany("a", "a", "b"), uint(0))
+	assert.Equal(t, c.AppendRun("b", 4), uint(3), "Expected the row index of the first row of the run")
+	assert.Equal(t, c.AppendRun("c", 0), uint(7), "Expected the row count when count is 0")
+	assert.Equal(t, c.AppendMany(), uint(7), "Expected the row count when there are no values")
+	assert.Equal(t, c.RowCount(), uint(7))
+	assert.Equal(t, runs(c), []column.Run{{Index: 0, Length: 2, Value: "a"}, {Index: 2, Length: 5, Value: "b"}})
+}
+
+func testIterationsAgree(t *testing.T, newColumn NewFn) {
+	c := populated(newColumn)
+	expected := []interface{}{"a", "a", "b", 1, 1, 1, nil, "a"}
+	assert.Equal(t, values(c), expected)
+
+	all := []interface{}{}
+	for index, value := range c.All() {
+		assert.Equal(t, index, uint(len(all)))
+		all = append(all, value)
+	}
+	assert.Equal(t, all, expected)
+
+	err := c.IterateContext(context.Background(), func(index uint, value interface{}) {
+		if value != expected[index] {
+			t.Error("Unexpected value for row", index, value)
+		}
+	})
+	assert.Nil(t, err, "Unexpected Error")
+
+	next := uint(len(expected))
+	c.IterateReverse(func(index uint, value interface{}) {
+		next--
+		if index != next || value != expected[index] {
+			t.Error("Unexpected row in reverse", index, value)
+		}
+	})
+	assert.Equal(t, next, uint(0), "Expected every row in reverse")
+
+	seq := []column.Run{}
+	for run := range c.Runs() {
+		seq = append(seq, run)
+	}
+	assert.Equal(t, seq, runs(c))
+	assert.Equal(t, uint(len(seq)), c.BlockCount())
+}
+
+func testBreak(t *testing.T, newColumn NewFn) {
+	c := populated(newColumn)
+	count := 0
+	for range c.All() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, count, 3)
+
+	for range c.Runs() {
+		break
+	}
+	// the column must still be usable once the loops have finished
+	c.Append("b")
+	assert.Equal(t, c.RowCount(), uint(9))
+}
+
+func testIterateContextCancelled(t *testing.T, newColumn NewFn) {
+	c := populated(newColumn)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.IterateContext(ctx, func(index uint, value interface{}) {
+		t.Error("Unexpected row", index)
+	})
+	assert.Equal(t, err, context.Canceled)
+}
+
+func testWriteRead(t *testing.T, newColumn NewFn) {
+	for _, c := range []column.Column{newColumn(), populated(newColumn)} {
+		buf := new(bytes.Buffer)
+		err := c.Write(buf)
+		assert.Nil(t, err, "Unexpected Write Error")
+
+		read := newColumn()
+		read.Append("Overwritten")
+		err = read.Read(buf)
+		assert.Nil(t, err, "Unexpected Read Error")
+		assert.Equal(t, read.RowCount(), c.RowCount())
+		assert.Equal(t, read.BlockCount(), c.BlockCount())
+		assert.Equal(t, runs(read), runs(c))
+
+		// a column read from a stream can be appended to
+		next := read.RowCount()
+		assert.Equal(t, read.Append("z"), next)
+	}
+}
+
+func testReadContextCancelled(t *testing.T, newColumn NewFn) {
+	buf := new(bytes.Buffer)
+	err := populated(newColumn).Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = newColumn().ReadContext(ctx, buf)
+	assert.Equal(t, err, context.Canceled)
+}
+
+func testConcurrentAppend(t *testing.T, newColumn NewFn) {
+	c := newColumn()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Append(i % 3)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, c.RowCount(), uint(4000))
+	var rows uint
+	var previous interface{} = -1
+	c.IterateRuns(func(index uint, length uint, value interface{}) {
+		if index != rows || value == previous {
+			t.Error("Unexpected run", index, length, value)
+		}
+		rows += length
+		previous = value
+	})
+	assert.Equal(t, rows, uint(4000))
+}
@@ -0,0 +1,172 @@
+package column
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+)
+
+// the type tags written at the start of each persisted format
+const (
+	MagicRleList = "RLELIST"   // written by rlelist.RleList
+	MagicBlock   = "RLEARRAY"  // written by block.Block
+	MagicColumn  = "RLECOLUMN" // the versioned format written by Write
+)
+
+// version of the format written by Write
+const FormatVersion uint = 1
+
+// number of bytes examined by DetectFormat, enough to hold the type tag of every format
+const detectLength = 64
+
+var (
+	ErrUnknownFormat      = errors.New("column: stream is not in a known format")
+	ErrNotColumn          = errors.New("column: tried to load a stream that is not RLECOLUMN")
+	ErrUnsupportedVersion = errors.New("column: stream was written by an unsupported version of the format")
+	ErrInvalidRuns        = errors.New("column: runs are not consistent with the row count")
+)
+
+// values are stored as interface{} so gob must know every concrete type written, the built in types are registered by
+// gob itself and time.Time, as stored by table.ReadCSV, is registered here for every format that stores column values
+func init() {
+	gob.Register(time.Time{})
+}
+
+// Format identifies the layout of a persisted column
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatRleList        // RLELIST, written by rlelist.RleList
+	FormatBlock          // RLEARRAY, written by block.Block
+	FormatColumn         // RLECOLUMN, the versioned format written by Write
+)
+
+// returns the type tag of the format
+func (f Format) String() string {
+	switch f {
+	case FormatRleList:
+		return MagicRleList
+	case FormatBlock:
+		return MagicBlock
+	case FormatColumn:
+		return MagicColumn
+	}
+	return "UNKNOWN"
+}
+
+// returns the format of the stream from its type tag without consuming any of it
+// returns ErrUnknownFormat if the stream does not start with a known type tag
+func DetectFormat(reader *bufio.Reader) (Format, error) {
+	head, err := reader.Peek(detectLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return FormatUnknown, err
+	}
+
+	var typeCheck string
+	if gob.NewDecoder(bytes.NewReader(head)).Decode(&typeCheck) != nil {
+		return FormatUnknown, ErrUnknownFormat
+	}
+	switch typeCheck {
+	case MagicRleList:
+		return FormatRleList, nil
+	case MagicBlock:
+		return FormatBlock, nil
+	case MagicColumn:
+		return FormatColumn, nil
+	}
+	return FormatUnknown, ErrUnknownFormat
+}
+
+// writes the column to a writer in the versioned RLECOLUMN format, which can be read into any Column implementation
+// the stream holds the type tag, the format version, the row and run counts and then each run
+func Write(writer io.Writer, c Column) error {
+	enc := gob.NewEncoder(writer)
+
+	// the runs are collected first so the counts agree with the runs even if rows are appended while writing
+	runs := []Run{}
+	var rowCount uint
+	c.IterateRuns(func(index uint, length uint, value interface{}) {
+		runs = append(runs, Run{Index: index, Length: length, Value: value})
+		rowCount += length
+	})
+
+	for _, v := range []interface{}{MagicColumn, FormatVersion, rowCount, uint(len(runs))} {
+		err := enc.Encode(v)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range runs {
+		err := enc.Encode(&runs[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reads a column written by Write, appending its rows to c
+// the whole stream is decoded and checked before any rows are appended, so if an error occurs c is left unchanged
+func Read(reader io.Reader, c Column) error {
+	dec := gob.NewDecoder(reader)
+
+	// check we are decoding the correct type and version
+	var typeCheck string
+	err := dec.Decode(&typeCheck)
+	if err != nil {
+		return err
+	}
+	if typeCheck != MagicColumn {
+		return ErrNotColumn
+	}
+
+	var version uint
+	err = dec.Decode(&version)
+	if err != nil {
+		return err
+	}
+	if version == 0 || version > FormatVersion {
+		return ErrUnsupportedVersion
+	}
+
+	var rowCount, runCount uint
+	err = dec.Decode(&rowCount)
+	if err != nil {
+		return err
+	}
+	err = dec.Decode(&runCount)
+	if err != nil {
+		return err
+	}
+	// every run holds at least one row, so the run count bounds the decoding by the row count
+	if runCount > rowCount || (runCount == 0) != (rowCount == 0) {
+		return ErrInvalidRuns
+	}
+
+	runs := []Run{}
+	var rows uint
+	for i := uint(0); i < runCount; i++ {
+		var run Run
+		err = dec.Decode(&run)
+		if err != nil {
+			return err
+		}
+		if run.Length == 0 || run.Index != rows || run.Length > rowCount-rows {
+			return ErrInvalidRuns
+		}
+		rows += run.Length
+		runs = append(runs, run)
+	}
+	if rows != rowCount {
+		return ErrInvalidRuns
+	}
+
+	for _, run := range runs {
+		c.AppendRun(run.Value, run.Length)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package column_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/rlelist"
+)
+
+// collects the values stored in the column in row order
+func values(c column.Column) []interface{} {
+	result := []interface{}{}
+	c.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestDetectFormat(t *testing.T) {
+	list := rlelist.New()
+	list.AppendMany("a", "b")
+	b := block.New(10)
+	b.AppendMany("a", "b")
+
+	tests := []struct {
+		write    func(buf *bytes.Buffer) error
+		expected column.Format
+	}{
+		{func(buf *bytes.Buffer) error { return list.Write(buf) }, column.FormatRleList},
+		{func(buf *bytes.Buffer) error { return b.Write(buf) }, column.FormatBlock},
+		{func(buf *bytes.Buffer) error { return column.Write(buf, b) }, column.FormatColumn},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		assert.Nil(t, test.write(buf), "Unexpected Write Error")
+		length := buf.Len()
+
+		reader := bufio.NewReader(buf)
+		format, err := column.DetectFormat(reader)
+		assert.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, format, test.expected)
+		assert.Equal(t, reader.Buffered(), length, "Expected detection not to consume the stream")
+	}
+
+	_, err := column.DetectFormat(bufio.NewReader(bytes.NewBufferString("Invalid Data")))
+	assert.Equal(t, err, column.ErrUnknownFormat)
+	_, err = column.DetectFormat(bufio.NewReader(new(bytes.Buffer)))
+	assert.Equal(t, err, column.ErrUnknownFormat)
+
+	buf := new(bytes.Buffer)
+	gob.NewEncoder(buf).Encode("SOMETHING ELSE")
+	_, err = column.DetectFormat(bufio.NewReader(buf))
+	assert.Equal(t, err, column.ErrUnknownFormat)
+}
+
+func TestWriteReadAcrossImplementations(t *testing.T) {
+	list := rlelist.New()
+	list.AppendMany("a", "a", nil, 2, 2.5)
+	list.AppendRun("b", 100)
+
+	buf := new(bytes.Buffer)
+	err := column.Write(buf, list)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	b := block.New(0)
+	err = column.Read(buf, b)
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, values(b), values(list))
+	assert.Equal(t, b.BlockCount(), list.BlockCount())
+
+	buf.Reset()
+	err = column.Write(buf, block.New(0))
+	assert.Nil(t, err, "Unexpected Write Error")
+	empty := rlelist.New()
+	err = column.Read(buf, empty)
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, empty.RowCount(), uint(0))
+}
+
+// encodes a stream in the RLECOLUMN layout from the parts given, which need not be valid
+func encodeStream(t *testing.T, parts ...interface{}) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	enc := gob.NewEncoder(buf)
+	for _, part := range parts {
+		assert.Nil(t, enc.Encode(part), "Unexpected Encode Error")
+	}
+	return buf
+}
+
+func TestReadRejectsInvalidStreams(t *testing.T) {
+	run := func(index uint, length uint) *column.Run {
+		return &column.Run{Index: index, Length: length, Value: "a"}
+	}
+	tests := []struct {
+		name     string
+		stream   *bytes.Buffer
+		expected error
+	}{
+		{"wrong type tag", encodeStream(t, column.MagicBlock), column.ErrNotColumn},
+		{"future version", encodeStream(t, column.MagicColumn, column.FormatVersion+1), column.ErrUnsupportedVersion},
+		{"more runs than rows", encodeStream(t, column.MagicColumn, column.FormatVersion, uint(1), uint(2)), column.ErrInvalidRuns},
+		{"gap between runs", encodeStream(t, column.MagicColumn, column.FormatVersion, uint(3), uint(2), run(0, 1), run(2, 1)), column.ErrInvalidRuns},
+		{"empty run", encodeStream(t, column.MagicColumn, column.FormatVersion, uint(2), uint(2), run(0, 0)), column.ErrInvalidRuns},
+		{"runs short of the row count", encodeStream(t, column.MagicColumn, column.FormatVersion, uint(3), uint(1), run(0, 2)), column.ErrInvalidRuns},
+	}
+
+	for _, test := range tests {
+		b := block.New(0)
+		b.Append("Existing")
+		err := column.Read(test.stream, b)
+		assert.Equal(t, err, test.expected, test.name)
+		assert.Equal(t, values(b), []interface{}{"Existing"}, "Expected the column to be unchanged", test.name)
+	}
+}
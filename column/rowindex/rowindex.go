@@ -1,6 +1,7 @@
 package rowindex
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -26,3 +27,79 @@ func (r *RowIndex) Append(index uint, length uint) {
 		length: length,
 	})
 }
+
+// appends a range of rows like Append, but extends the last range instead if the new range directly follows it
+func (r *RowIndex) Extend(index uint, length uint) {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(r.items) > 0 {
+		last := r.items[len(r.items)-1]
+		if last.index+last.length == index {
+			last.length += length
+			return
+		}
+	}
+	r.items = append(r.items, &indexItem{
+		index:  index,
+		length: length,
+	})
+}
+
+// returns a new RowIndex holding every row in any of the indexes, as ranges in ascending row order
+// overlapping and adjacent ranges are combined
+func Union(indexes ...*RowIndex) *RowIndex {
+	items := []indexItem{}
+	for _, index := range indexes {
+		index.RLock()
+		for _, item := range index.items {
+			if item.length > 0 {
+				items = append(items, *item)
+			}
+		}
+		index.RUnlock()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].index < items[j].index
+	})
+
+	result := New()
+	for _, item := range items {
+		if len(result.items) > 0 {
+			last := result.items[len(result.items)-1]
+			if item.index <= last.index+last.length {
+				if end := item.index + item.length; end > last.index+last.length {
+					last.length = end - last.index
+				}
+				continue
+			}
+		}
+		result.items = append(result.items, &indexItem{index: item.index, length: item.length})
+	}
+	return result
+}
+
+// iterator function type declaration, called for each range of rows in the RowIndex
+type IteratorFn func(index uint, length uint)
+
+// iterates each range of rows in the order they were appended
+func (r *RowIndex) Iterate(f IteratorFn) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, item := range r.items {
+		f(item.index, item.length)
+	}
+}
+
+// returns the total number of rows covered by the RowIndex
+func (r *RowIndex) RowCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+
+	var count uint
+	for _, item := range r.items {
+		count += item.length
+	}
+	return count
+}
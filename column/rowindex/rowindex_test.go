@@ -11,3 +11,69 @@ func TestAppendToRowIndex(t *testing.T) {
 	ri.Append(1, 10)
 	ri.Append(40, 10)
 }
+
+func TestIterateAndRowCount(t *testing.T) {
+	ri := New()
+	ri.Append(0, 1)
+	ri.Append(1, 10)
+	ri.Append(40, 10)
+
+	var ranges [][2]uint
+	ri.Iterate(func(index uint, length uint) {
+		ranges = append(ranges, [2]uint{index, length})
+	})
+	if len(ranges) != 3 || ranges[2] != [2]uint{40, 10} {
+		t.Error("Unexpected ranges", ranges)
+	}
+	if ri.RowCount() != 21 {
+		t.Error("Expected 21 rows, got", ri.RowCount())
+	}
+}
+
+func TestExtendCombinesAdjacentRanges(t *testing.T) {
+	ri := New()
+	ri.Extend(0, 2)
+	ri.Extend(2, 3)
+	ri.Extend(10, 1)
+
+	var ranges [][2]uint
+	ri.Iterate(func(index uint, length uint) {
+		ranges = append(ranges, [2]uint{index, length})
+	})
+	if len(ranges) != 2 || ranges[0] != [2]uint{0, 5} || ranges[1] != [2]uint{10, 1} {
+		t.Error("Expected adjacent ranges to be combined", ranges)
+	}
+
+	// Append keeps every range as given
+	ri = New()
+	ri.Append(0, 2)
+	ri.Append(2, 3)
+	if ri.RowCount() != 5 || len(ri.items) != 2 {
+		t.Error("Expected Append not to combine ranges", ri.items)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	a := New()
+	a.Append(10, 5)
+	a.Append(30, 2)
+	b := New()
+	b.Append(0, 3)
+	b.Append(12, 8)
+	b.Append(20, 1)
+	b.Append(40, 0)
+
+	var ranges [][2]uint
+	Union(a, b).Iterate(func(index uint, length uint) {
+		ranges = append(ranges, [2]uint{index, length})
+	})
+	expected := [][2]uint{{0, 3}, {10, 11}, {30, 2}}
+	if len(ranges) != len(expected) {
+		t.Fatal("Unexpected ranges", ranges)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Error("Unexpected ranges", ranges)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package segment
+
+// Implements a column split into segments, each a block.Block stored in its own file
+// Every segment file carries a Bloom filter of the segment's values ahead of the Block, so equality lookups only need
+// the filters, which are held in memory, to skip segments that cannot contain the value without loading them
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/bloom"
+	"github.com/lummie/golib/column/rowindex"
+)
+
+// false positive rate of the Bloom filter written with each segment
+const FalsePositiveRate = 0.01
+
+const segmentFileExt = ".seg"
+
+var ErrSegmentNotFound = errors.New("segment: segment not found")
+
+// Header is the part of a segment file stored ahead of the Block
+type Header struct {
+	RowCount uint          // number of rows in the segment
+	Filter   *bloom.Filter // filter of the values in the segment
+}
+
+// writes the Block as a segment, a header holding the row count and a Bloom filter of the values followed by the Block
+// segments are not expected to change once written, so the Block must not be appended to while it is being written
+func Write(writer io.Writer, b *block.Block) error {
+	distinct := b.Distinct()
+	filter := bloom.NewWithEstimates(uint(len(distinct)), FalsePositiveRate)
+	for _, vc := range distinct {
+		filter.Add(vc.Value)
+	}
+
+	enc := gob.NewEncoder(writer)
+	err := enc.Encode("RLESEGMENT")
+	if err != nil {
+		return err
+	}
+
+	err = enc.Encode(b.RowCount())
+	if err != nil {
+		return err
+	}
+
+	err = enc.Encode(filter)
+	if err != nil {
+		return err
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+	return enc.Encode(b)
+}
+
+// reads just the header of a segment
+func ReadHeader(reader io.Reader) (*Header, error) {
+	header, _, err := read(reader, false)
+	return header, err
+}
+
+// reads the header and Block of a segment
+func Read(reader io.Reader) (*Header, *block.Block, error) {
+	return read(reader, true)
+}
+
+func read(reader io.Reader, withBlock bool) (*Header, *block.Block, error) {
+	dec := gob.NewDecoder(reader)
+
+	// check we are decoding the correct type
+	var typeCheck string
+	err := dec.Decode(&typeCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+	if typeCheck != "RLESEGMENT" {
+		return nil, nil, errors.New("Tried to load a stream that is not RLESEGMENT")
+	}
+
+	header := &Header{Filter: bloom.New(1, 1)}
+	err = dec.Decode(&header.RowCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = dec.Decode(header.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !withBlock {
+		return header, nil, nil
+	}
+
+	b := block.New(0)
+	err = dec.Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, b, nil
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	STORE
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// Store is a column held as a directory of segment files, the rows of each segment following those of the previous one
+type Store struct {
+	sync.RWMutex
+	dir      string
+	segments []*segmentInfo
+	loads    uint64 // number of segments loaded by lookups
+}
+
+type segmentInfo struct {
+	path     string
+	firstRow uint          // row of the column held in the first row of the segment
+	rowCount uint          // number of rows in the segment
+	filter   *bloom.Filter // filter of the values in the segment
+}
+
+// Opens the segmented column stored in dir, creating the directory if needed
+// only the segment headers are read, the Blocks are loaded when needed
+func Open(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+segmentFileExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	store := &Store{dir: dir}
+	for _, path := range paths {
+		header, err := readHeaderFile(path)
+		if err != nil {
+			return nil, err
+		}
+		store.segments = append(store.segments, &segmentInfo{
+			path:     path,
+			firstRow: store.rowCountLocked(),
+			rowCount: header.RowCount,
+			filter:   header.Filter,
+		})
+	}
+	return store, nil
+}
+
+// writes the Block as a new segment following the existing segments
+func (r *Store) AddSegment(b *block.Block) error {
+	r.Lock()
+	defer r.Unlock()
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%08d%s", len(r.segments), segmentFileExt))
+	tempPath := path + ".tmp"
+	fo, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(fo)
+	err = Write(w, b)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := fo.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// only complete segments are renamed to the name Open looks for
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	header, err := readHeaderFile(path)
+	if err != nil {
+		return err
+	}
+	r.segments = append(r.segments, &segmentInfo{
+		path:     path,
+		firstRow: r.rowCountLocked(),
+		rowCount: header.RowCount,
+		filter:   header.Filter,
+	})
+	return nil
+}
+
+// returns the number of segments in the column
+func (r *Store) SegmentCount() int {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.segments)
+}
+
+// returns the number of rows in the column
+func (r *Store) RowCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+	return r.rowCountLocked()
+}
+
+func (r *Store) rowCountLocked() uint {
+	if len(r.segments) == 0 {
+		return 0
+	}
+	last := r.segments[len(r.segments)-1]
+	return last.firstRow + last.rowCount
+}
+
+// reads the Block of segment i
+func (r *Store) ReadSegment(i int) (*block.Block, error) {
+	r.RLock()
+	if i < 0 || i >= len(r.segments) {
+		r.RUnlock()
+		return nil, ErrSegmentNotFound
+	}
+	path := r.segments[i].path
+	r.RUnlock()
+
+	return readBlockFile(path)
+}
+
+// returns the rows of the column holding value
+// segments whose Bloom filter shows they cannot hold the value are skipped without being read
+func (r *Store) Lookup(value interface{}) (*rowindex.RowIndex, error) {
+	r.RLock()
+	segments := append([]*segmentInfo{}, r.segments...)
+	r.RUnlock()
+
+	rows := rowindex.New()
+	for _, s := range segments {
+		if !s.filter.MayContain(value) {
+			continue
+		}
+
+		atomic.AddUint64(&r.loads, 1)
+		b, err := readBlockFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		b.IterateRuns(func(index uint, length uint, v interface{}) {
+			if v == value {
+				rows.Extend(s.firstRow+index, length)
+			}
+		})
+	}
+	return rows, nil
+}
+
+func readHeaderFile(path string) (*Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHeader(bufio.NewReader(f))
+}
+
+func readBlockFile(path string) (*block.Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	_, b, err := Read(bufio.NewReader(f))
+	return b, err
+}
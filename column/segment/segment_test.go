@@ -0,0 +1,115 @@
+package segment
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/rowindex"
+)
+
+// creates a block holding the values in order
+func blockOf(values ...interface{}) *block.Block {
+	b := block.New(len(values))
+	for _, v := range values {
+		b.Append(v)
+	}
+	return b
+}
+
+// returns the ranges held in a row set as pairs of starting row and length
+func rangesOf(rows *rowindex.RowIndex) [][2]uint {
+	result := [][2]uint{}
+	rows.Iterate(func(index uint, length uint) {
+		result = append(result, [2]uint{index, length})
+	})
+	return result
+}
+
+func TestWriteRead(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := Write(buf, blockOf("a", "a", "b"))
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	header, b, err := Read(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, header.RowCount, uint(3))
+	assert.Equal(t, header.Filter.MayContain("a"), true)
+	assert.Equal(t, header.Filter.MayContain("b"), true)
+	assert.Equal(t, b.RowCount(), uint(3))
+	assert.Equal(t, b.BlockCount(), uint(2))
+
+	header, err = ReadHeader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err, "Unexpected ReadHeader Error")
+	assert.Equal(t, header.RowCount, uint(3))
+}
+
+func TestReadInvalidData(t *testing.T) {
+	_, _, err := Read(bytes.NewBufferString("Invalid Data"))
+	assert.NotNil(t, err, "Expected an error reading invalid data")
+}
+
+func TestStoreLookupSkipsSegments(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	assert.Nil(t, err, "Unexpected Open Error")
+
+	assert.Nil(t, store.AddSegment(blockOf("a", "a", "b")))
+	assert.Nil(t, store.AddSegment(blockOf("c", "c")))
+	assert.Nil(t, store.AddSegment(blockOf("b", "d")))
+	assert.Equal(t, store.SegmentCount(), 3)
+	assert.Equal(t, store.RowCount(), uint(7))
+
+	rows, err := store.Lookup("b")
+	assert.Nil(t, err, "Unexpected Lookup Error")
+	assert.Equal(t, rangesOf(rows), [][2]uint{{2, 1}, {5, 1}})
+
+	rows, err = store.Lookup("c")
+	assert.Nil(t, err, "Unexpected Lookup Error")
+	assert.Equal(t, rangesOf(rows), [][2]uint{{3, 2}})
+
+	// a value in no segment should not normally need any segment to be loaded
+	loads := store.loads
+	rows, err = store.Lookup("missing")
+	assert.Nil(t, err, "Unexpected Lookup Error")
+	assert.Equal(t, rangesOf(rows), [][2]uint{})
+	assert.Equal(t, store.loads, loads, "Expected every segment to be skipped")
+}
+
+func TestStoreLookupNegativeZero(t *testing.T) {
+	store, err := Open(t.TempDir())
+	assert.Nil(t, err, "Unexpected Open Error")
+	assert.Nil(t, store.AddSegment(blockOf(1.5, 0.0)))
+
+	rows, err := store.Lookup(math.Copysign(0, -1))
+	assert.Nil(t, err, "Unexpected Lookup Error")
+	assert.Equal(t, rangesOf(rows), [][2]uint{{1, 1}}, "Expected -0 to find the rows holding 0")
+}
+
+func TestStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := Open(dir)
+	store.AddSegment(blockOf(1, 1, 2))
+	store.AddSegment(blockOf(3))
+
+	// a partially written segment is ignored
+	os.WriteFile(filepath.Join(dir, "00000002.seg.tmp"), []byte("partial"), 0644)
+
+	store, err := Open(dir)
+	assert.Nil(t, err, "Unexpected Open Error")
+	assert.Equal(t, store.SegmentCount(), 2)
+	assert.Equal(t, store.RowCount(), uint(4))
+
+	rows, _ := store.Lookup(3)
+	assert.Equal(t, rangesOf(rows), [][2]uint{{3, 1}})
+
+	b, err := store.ReadSegment(0)
+	assert.Nil(t, err, "Unexpected ReadSegment Error")
+	assert.Equal(t, b.RowCount(), uint(3))
+	_, err = store.ReadSegment(2)
+	assert.Equal(t, err, ErrSegmentNotFound)
+}
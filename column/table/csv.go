@@ -0,0 +1,244 @@
+package table
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lummie/golib/column/block"
+)
+
+var (
+	ErrNoHeader      = errors.New("table: CSV has no header row")
+	ErrValueType     = errors.New("table: CSV value does not match the column type")
+	ErrSchemaColumns = errors.New("table: schema names a column that is not in the CSV header")
+)
+
+// default number of rows examined to infer the column types
+const DefaultInferRows = 1000
+
+// ColumnType is the type of the values stored in a column imported from CSV
+type ColumnType int
+
+const (
+	TypeInfer  ColumnType = iota // infer the type from the first rows of the file
+	TypeString                   // string values, stored as is
+	TypeInt                      // int64 values
+	TypeFloat                    // float64 values
+	TypeBool                     // bool values, as accepted by strconv.ParseBool
+	TypeTime                     // time.Time values in RFC 3339 format
+)
+
+// the types tried in order when inferring the type of a column, the first to parse every value is chosen
+var inferOrder = []ColumnType{TypeInt, TypeFloat, TypeBool, TypeTime}
+
+// returns the name of the type
+func (t ColumnType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeTime:
+		return "time"
+	}
+	return "infer"
+}
+
+// CSVOptions controls how a CSV file is imported
+// the zero value reads comma separated values and infers the type of every column from the first DefaultInferRows rows
+type CSVOptions struct {
+	Comma     rune                  // field delimiter, ',' if zero
+	Schema    map[string]ColumnType // types of columns by name, columns not listed or listed as TypeInfer are inferred
+	InferRows int                   // number of rows examined to infer column types, DefaultInferRows if zero
+}
+
+// ImportStats reports the rows imported from a CSV file and how well each column compressed
+type ImportStats struct {
+	Rows    uint          // number of rows imported
+	Columns []ColumnStats // one per column in header order
+}
+
+// ColumnStats reports the type of an imported column and how well its rows compressed into runs
+type ColumnStats struct {
+	Name string
+	Type ColumnType
+	Runs uint    // number of runs stored
+	Rows uint    // number of rows stored
+	Nils uint    // number of empty fields stored as nil
+	Rate float64 // rows per run
+}
+
+// returns a report of the import, one line per column
+func (s *ImportStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "imported %d rows into %d columns\n", s.Rows, len(s.Columns))
+	for _, c := range s.Columns {
+		fmt.Fprintf(&b, "  %s (%s): %d runs, %.2f rows per run, %d nil\n", c.Name, c.Type, c.Runs, c.Rate, c.Nils)
+	}
+	return b.String()
+}
+
+// reads a CSV file with a header row into a new Table, with one column per header field
+// the first InferRows rows are buffered to infer the type of any column without a type in the schema, the remaining
+// rows are then streamed into the columns. Empty fields are stored as nil, except in string columns.
+// returns ErrNoHeader if the file is empty, ErrSchemaColumns if the schema names a column not in the header, and an
+// error wrapping ErrValueType giving the row and column if a value cannot be parsed as the type of its column
+func ReadCSV(reader io.Reader, opts CSVOptions) (*Table, *ImportStats, error) {
+	cr := csv.NewReader(reader)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.ReuseRecord = true
+	inferRows := opts.InferRows
+	if inferRows <= 0 {
+		inferRows = DefaultInferRows
+	}
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, ErrNoHeader
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	names := append([]string{}, header...)
+
+	types := make([]ColumnType, len(names))
+	for i, name := range names {
+		types[i] = opts.Schema[name]
+	}
+	for name := range opts.Schema {
+		if !slices.Contains(names, name) {
+			return nil, nil, ErrSchemaColumns
+		}
+	}
+
+	// buffer the rows used for inference
+	sample := [][]string{}
+	for len(sample) < inferRows {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		sample = append(sample, append([]string{}, record...))
+	}
+	for i := range types {
+		if types[i] == TypeInfer {
+			types[i] = inferType(sample, i)
+		}
+	}
+
+	t := New()
+	columns := make([]*block.Block, len(names))
+	for i, name := range names {
+		columns[i] = block.New(0)
+		err = t.AddColumn(name, columns[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stats := &ImportStats{Columns: make([]ColumnStats, len(names))}
+	appendRecord := func(record []string) error {
+		for i, field := range record {
+			value, err := parseField(field, types[i])
+			if err != nil {
+				return fmt.Errorf("%w: row %d column %q value %q is not %s", ErrValueType, stats.Rows, names[i], field, types[i])
+			}
+			if value == nil {
+				stats.Columns[i].Nils++
+			}
+			columns[i].Append(value)
+		}
+		stats.Rows++
+		return nil
+	}
+
+	for _, record := range sample {
+		err = appendRecord(record)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		err = appendRecord(record)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i, name := range names {
+		c := &stats.Columns[i]
+		c.Name = name
+		c.Type = types[i]
+		c.Rows = columns[i].RowCount()
+		c.Runs = columns[i].BlockCount()
+		if c.Runs > 0 {
+			c.Rate = float64(c.Rows) / float64(c.Runs)
+		}
+	}
+	return t, stats, nil
+}
+
+// returns the first type in inferOrder that parses every non empty field of column i in the sample, or TypeString
+func inferType(sample [][]string, i int) ColumnType {
+	for _, t := range inferOrder {
+		matches := false
+		for _, record := range sample {
+			if record[i] == "" {
+				continue
+			}
+			if _, err := parseField(record[i], t); err != nil {
+				matches = false
+				break
+			}
+			matches = true
+		}
+		if matches {
+			return t
+		}
+	}
+	return TypeString
+}
+
+// parses a field as type t, returning nil for an empty field unless t is TypeString
+func parseField(field string, t ColumnType) (interface{}, error) {
+	if t == TypeString {
+		return field, nil
+	}
+	if field == "" {
+		return nil, nil
+	}
+
+	switch t {
+	case TypeInt:
+		return strconv.ParseInt(field, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(field, 64)
+	case TypeBool:
+		return strconv.ParseBool(field)
+	case TypeTime:
+		return time.Parse(time.RFC3339Nano, field)
+	}
+	return field, nil
+}
@@ -0,0 +1,110 @@
+package table
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/block"
+)
+
+const citiesCSV = `city,country,population,capital,founded
+London,UK,8900000,true,0047-01-01T00:00:00Z
+Leeds,UK,790000,false,
+Paris,France,2100000,true,
+Lyon,France,,false,
+`
+
+func TestReadCSVInfersTypes(t *testing.T) {
+	table, stats, err := ReadCSV(strings.NewReader(citiesCSV), CSVOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, table.Names(), []string{"city", "country", "population", "capital", "founded"})
+	assert.Equal(t, table.RowCount(), uint(4))
+
+	column, _ := table.Column("population")
+	assert.Equal(t, valuesOf(column), []interface{}{int64(8900000), int64(790000), int64(2100000), nil})
+	column, _ = table.Column("capital")
+	assert.Equal(t, valuesOf(column), []interface{}{true, false, true, false})
+	column, _ = table.Column("founded")
+	assert.Equal(t, valuesOf(column)[0], time.Date(47, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, stats.Rows, uint(4))
+	assert.Equal(t, stats.Columns[1], ColumnStats{Name: "country", Type: TypeString, Runs: 2, Rows: 4, Rate: 2})
+	assert.Equal(t, stats.Columns[2].Type, TypeInt)
+	assert.Equal(t, stats.Columns[2].Nils, uint(1))
+	assert.Equal(t, stats.Columns[4].Type, TypeTime)
+	if !strings.Contains(stats.String(), "country (string): 2 runs, 2.00 rows per run, 0 nil") {
+		t.Error("Unexpected report", stats.String())
+	}
+}
+
+func TestReadCSVWriteRead(t *testing.T) {
+	table, _, err := ReadCSV(strings.NewReader(citiesCSV), CSVOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+
+	// every imported type, including time.Time, can be persisted and read back in both formats
+	for _, name := range table.Names() {
+		imported, _ := table.Column(name)
+
+		buf := new(bytes.Buffer)
+		assert.Nil(t, imported.Write(buf), "Unexpected Write Error", name)
+		read := block.New(0)
+		assert.Nil(t, read.Read(buf), "Unexpected Read Error", name)
+		assert.Equal(t, valuesOf(read), valuesOf(imported), name)
+
+		buf.Reset()
+		assert.Nil(t, column.Write(buf, imported), "Unexpected Write Error", name)
+		read = block.New(0)
+		assert.Nil(t, column.Read(buf, read), "Unexpected Read Error", name)
+		assert.Equal(t, valuesOf(read), valuesOf(imported), name)
+	}
+}
+
+func TestReadCSVWithSchema(t *testing.T) {
+	opts := CSVOptions{Schema: map[string]ColumnType{"population": TypeFloat, "capital": TypeString}}
+	table, _, err := ReadCSV(strings.NewReader(citiesCSV), opts)
+	assert.Nil(t, err, "Unexpected Error")
+
+	column, _ := table.Column("population")
+	assert.Equal(t, valuesOf(column)[0], 8900000.0)
+	column, _ = table.Column("capital")
+	assert.Equal(t, valuesOf(column)[0], "true")
+
+	_, _, err = ReadCSV(strings.NewReader(citiesCSV), CSVOptions{Schema: map[string]ColumnType{"missing": TypeInt}})
+	assert.Equal(t, err, ErrSchemaColumns)
+}
+
+func TestReadCSVValueAfterInference(t *testing.T) {
+	// only the first row is used for inference, so the second row's value does not match the inferred type
+	input := "id;value\n1;10\n2;ten\n"
+	_, _, err := ReadCSV(strings.NewReader(input), CSVOptions{Comma: ';', InferRows: 1})
+	assert.Equal(t, errors.Is(err, ErrValueType), true, "Expected ErrValueType, got", err)
+	if err != nil && !strings.Contains(err.Error(), `row 1 column "value" value "ten" is not int`) {
+		t.Error("Expected the error to locate the value", err)
+	}
+
+	table, _, err := ReadCSV(strings.NewReader(input), CSVOptions{Comma: ';'})
+	assert.Nil(t, err, "Unexpected Error")
+	column, _ := table.Column("value")
+	assert.Equal(t, valuesOf(column), []interface{}{"10", "ten"}, "Expected a column of strings when every row is sampled")
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	_, _, err := ReadCSV(strings.NewReader(""), CSVOptions{})
+	assert.Equal(t, err, ErrNoHeader)
+
+	_, _, err = ReadCSV(strings.NewReader("a,a\n1,2\n"), CSVOptions{})
+	assert.Equal(t, err, ErrColumnExists)
+
+	_, _, err = ReadCSV(strings.NewReader("a,b\n1,2\n3\n"), CSVOptions{})
+	assert.NotNil(t, err, "Expected an error for a row with too few fields")
+
+	table, stats, err := ReadCSV(strings.NewReader("a,b\n"), CSVOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, table.RowCount(), uint(0))
+	assert.Equal(t, stats.Columns[0].Type, TypeString, "Expected a column with no values to be a string column")
+}
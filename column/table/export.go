@@ -0,0 +1,173 @@
+package table
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/lummie/golib/column/block"
+)
+
+// ExportOptions selects the columns and rows written by WriteCSV and WriteJSONL
+// the zero value writes every column and every row
+type ExportOptions struct {
+	Columns []string // names of the columns to write in order, every column in the order they were added if empty
+	From    uint     // first row to write
+	Count   uint     // number of rows to write, every row from From if zero
+	Comma   rune     // CSV field delimiter, ',' if zero
+	Null    string   // CSV text written for nil values, an empty field if not set
+}
+
+// writes the selected rows as CSV with a header row of the column names
+// values are written in the form ReadCSV reads them, times in RFC 3339 format and nil values as opts.Null
+// returns ErrColumnNotFound if a selected column does not exist
+func (r *Table) WriteCSV(writer io.Writer, opts ExportOptions) error {
+	cw := csv.NewWriter(writer)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	var record []string
+	err := r.exportRows(opts, func(names []string) error {
+		record = make([]string, len(names))
+		return cw.Write(names)
+	}, func(values []interface{}) error {
+		for i, value := range values {
+			record[i] = formatField(value, opts.Null)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writes the selected rows as JSON Lines, one JSON object per row with a field per column in column order
+// nil values are written as null
+// returns ErrColumnNotFound if a selected column does not exist
+func (r *Table) WriteJSONL(writer io.Writer, opts ExportOptions) error {
+	bw := bufio.NewWriter(writer)
+
+	var keys [][]byte // the encoded column names
+	err := r.exportRows(opts, func(names []string) error {
+		keys = make([][]byte, len(names))
+		for i, name := range names {
+			key, err := json.Marshal(name)
+			if err != nil {
+				return err
+			}
+			keys[i] = key
+		}
+		return nil
+	}, func(values []interface{}) error {
+		bw.WriteByte('{')
+		for i, value := range values {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			bw.Write(data)
+		}
+		_, err := bw.WriteString("}\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// calls header with the names of the selected columns, then row with the values of each selected row in turn
+// the columns are read through Snapshot cursors, so the table lock is only held while the snapshots are taken and
+// rows are streamed one at a time without expanding the columns
+func (r *Table) exportRows(opts ExportOptions, header func(names []string) error, row func(values []interface{}) error) error {
+	r.RLock()
+	names := opts.Columns
+	if len(names) == 0 {
+		names = r.names
+	}
+	names = append([]string{}, names...)
+	columns, err := r.columnsLocked(names)
+	if err != nil {
+		r.RUnlock()
+		return err
+	}
+	snapshots := make([]*block.Snapshot, len(columns))
+	for i, column := range columns {
+		snapshots[i] = column.Snapshot()
+	}
+	r.RUnlock()
+
+	err = header(names)
+	if err != nil {
+		return err
+	}
+
+	// the rows in range, limited to the rows present in every snapshot
+	end := exportEnd(snapshots, opts)
+	if opts.From >= end {
+		return nil
+	}
+
+	cursors := make([]*block.Cursor, len(snapshots))
+	for i, s := range snapshots {
+		cursors[i] = s.Cursor()
+		cursors[i].Seek(opts.From)
+	}
+	values := make([]interface{}, len(cursors))
+	for index := opts.From; index < end; index++ {
+		for i, c := range cursors {
+			values[i] = c.Value()
+			c.Next()
+		}
+		err = row(values)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the row after the last row to export
+func exportEnd(snapshots []*block.Snapshot, opts ExportOptions) uint {
+	var end uint
+	for i, s := range snapshots {
+		if i == 0 || s.RowCount() < end {
+			end = s.RowCount()
+		}
+	}
+	if opts.Count > 0 && opts.From < end && opts.Count < end-opts.From {
+		end = opts.From + opts.Count
+	}
+	return end
+}
+
+// formats a value as a CSV field
+func formatField(value interface{}, null string) string {
+	switch v := value.(type) {
+	case nil:
+		return null
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,71 @@
+package table
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+// a table of cities read from citiesCSV
+func importedCities(t *testing.T) *Table {
+	table, _, err := ReadCSV(strings.NewReader(citiesCSV), CSVOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	return table
+}
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := importedCities(t).WriteCSV(buf, ExportOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.String(), citiesCSV, "Expected the export to reproduce the imported file")
+}
+
+func TestWriteCSVSelection(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := ExportOptions{Columns: []string{"population", "city"}, From: 2, Count: 5, Comma: ';', Null: "NULL"}
+	err := importedCities(t).WriteCSV(buf, opts)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.String(), "population;city\n2100000;Paris\nNULL;Lyon\n")
+
+	buf.Reset()
+	err = importedCities(t).WriteCSV(buf, ExportOptions{Columns: []string{"city"}, From: 10})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.String(), "city\n", "Expected only the header for rows beyond the end")
+
+	err = importedCities(t).WriteCSV(buf, ExportOptions{Columns: []string{"missing"}})
+	assert.Equal(t, err, ErrColumnNotFound)
+}
+
+func TestWriteJSONL(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := ExportOptions{Columns: []string{"city", "population", "founded"}, From: 0, Count: 2}
+	err := importedCities(t).WriteJSONL(buf, opts)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.String(), `{"city":"London","population":8900000,"founded":"0047-01-01T00:00:00Z"}`+"\n"+
+		`{"city":"Leeds","population":790000,"founded":null}`+"\n")
+
+	// every line is a valid JSON object
+	buf.Reset()
+	err = importedCities(t).WriteJSONL(buf, ExportOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, len(lines), 4)
+	for _, line := range lines {
+		row := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &row), "Unexpected JSON Error", line)
+		assert.Equal(t, len(row), 5)
+	}
+}
+
+func TestWriteEmptyTable(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := New().WriteJSONL(buf, ExportOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, buf.Len(), 0)
+
+	err = New().WriteCSV(buf, ExportOptions{})
+	assert.Nil(t, err, "Unexpected Error")
+}
@@ -0,0 +1,165 @@
+package table
+
+import (
+	"github.com/lummie/golib/column/block"
+)
+
+// the kind of join to perform
+type JoinKind int
+
+const (
+	InnerJoin JoinKind = iota // only rows with a matching key in both tables
+	LeftJoin                  // every row of the left table, with nil right values where there is no match
+)
+
+// Match pairs a range of rows in the left table with a range of rows in the right table
+// every left row in the range matches every right row in the range, so a Match stands for LeftLength x RightLength rows
+// of the joined result. For a left join, left rows without a match have a RightLength of 0
+type Match struct {
+	LeftIndex   uint // first row of the range in the left table
+	LeftLength  uint // number of rows in the left range
+	RightIndex  uint // first row of the range in the right table
+	RightLength uint // number of rows in the right range
+}
+
+// returns the number of rows the match contributes to the joined result
+func (m Match) Rows() uint {
+	if m.RightLength == 0 {
+		return m.LeftLength
+	}
+	return m.LeftLength * m.RightLength
+}
+
+// a range of rows in the build side of the join
+type rowRange struct {
+	index  uint
+	length uint
+}
+
+// joins two tables on equal key values, building a hash table over the runs of the right key column and probing it
+// with the runs of the left key column, so the cost depends on the number of runs rather than the number of rows
+// keys match when they are ==, nil keys never match. Matches are returned in left row order
+func HashJoin(left *Table, leftKey string, right *Table, rightKey string, kind JoinKind) ([]Match, error) {
+	leftColumn, err := left.Column(leftKey)
+	if err != nil {
+		return nil, err
+	}
+	rightColumn, err := right.Column(rightKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// build
+	build := make(map[interface{}][]rowRange)
+	rightColumn.IterateRuns(func(index uint, length uint, key interface{}) {
+		if key != nil {
+			build[key] = append(build[key], rowRange{index: index, length: length})
+		}
+	})
+
+	// probe
+	matches := []Match{}
+	leftColumn.IterateRuns(func(index uint, length uint, key interface{}) {
+		ranges := build[key]
+		if key == nil {
+			ranges = nil
+		}
+		if len(ranges) == 0 {
+			if kind == LeftJoin {
+				matches = append(matches, Match{LeftIndex: index, LeftLength: length})
+			}
+			return
+		}
+		for _, r := range ranges {
+			matches = append(matches, Match{
+				LeftIndex:   index,
+				LeftLength:  length,
+				RightIndex:  r.index,
+				RightLength: r.length,
+			})
+		}
+	})
+	return matches, nil
+}
+
+// builds the joined table for the matches returned by HashJoin
+// the result holds every left column followed by every right column, right columns whose name is already used by a left
+// column are renamed with the prefix "right."
+// within each match the rows are ordered by left row and then by right row
+func Materialise(left *Table, right *Table, matches []Match) (*Table, error) {
+	leftNames := left.Names()
+	rightNames := right.Names()
+
+	leftColumns := make([]*block.Block, len(leftNames))
+	for i, name := range leftNames {
+		column, err := left.Column(name)
+		if err != nil {
+			return nil, err
+		}
+		leftColumns[i] = column
+	}
+	rightColumns := make([]*block.Block, len(rightNames))
+	for i, name := range rightNames {
+		column, err := right.Column(name)
+		if err != nil {
+			return nil, err
+		}
+		rightColumns[i] = column
+	}
+
+	var rows uint
+	for _, m := range matches {
+		rows += m.Rows()
+	}
+
+	result := New()
+	for i, column := range leftColumns {
+		joined := block.New(0)
+		for _, m := range matches {
+			repeat := m.RightLength
+			if repeat == 0 {
+				repeat = 1
+			}
+			// each left row is repeated once for every right row it matches
+			column.IterateRunsRange(m.LeftIndex, m.LeftLength, func(index uint, length uint, value interface{}) {
+				joined.AppendRun(value, length*repeat)
+			})
+		}
+		if joined.RowCount() != rows {
+			return nil, ErrRowCount
+		}
+		err := result.AddColumn(leftNames[i], joined)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, column := range rightColumns {
+		joined := block.New(0)
+		for _, m := range matches {
+			if m.RightLength == 0 {
+				joined.AppendRun(nil, m.LeftLength)
+				continue
+			}
+			// the right range is repeated once for every left row
+			for n := uint(0); n < m.LeftLength; n++ {
+				column.IterateRunsRange(m.RightIndex, m.RightLength, func(index uint, length uint, value interface{}) {
+					joined.AppendRun(value, length)
+				})
+			}
+		}
+		if joined.RowCount() != rows {
+			return nil, ErrRowCount
+		}
+
+		name := rightNames[i]
+		if _, err := result.Column(name); err == nil {
+			name = "right." + name
+		}
+		err := result.AddColumn(name, joined)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func ordersAndCustomers(t *testing.T) (*Table, *Table) {
+	orders := New()
+	assert.Nil(t, orders.AddColumn("customer", columnOf(1, 1, 2, 3, 3, nil)))
+	assert.Nil(t, orders.AddColumn("amount", columnOf(10, 20, 30, 40, 50, 60)))
+
+	customers := New()
+	assert.Nil(t, customers.AddColumn("customer", columnOf(1, 2, 2, 4)))
+	assert.Nil(t, customers.AddColumn("name", columnOf("ann", "bob", "bobby", "dan")))
+	return orders, customers
+}
+
+func TestHashJoinInner(t *testing.T) {
+	orders, customers := ordersAndCustomers(t)
+
+	matches, err := HashJoin(orders, "customer", customers, "customer", InnerJoin)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, matches, []Match{
+		{LeftIndex: 0, LeftLength: 2, RightIndex: 0, RightLength: 1},
+		{LeftIndex: 2, LeftLength: 1, RightIndex: 1, RightLength: 2},
+	})
+
+	joined, err := Materialise(orders, customers, matches)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, joined.Names(), []string{"customer", "amount", "right.customer", "name"})
+	amount, _ := joined.Column("amount")
+	name, _ := joined.Column("name")
+	assert.Equal(t, valuesOf(amount), []interface{}{10, 20, 30, 30})
+	assert.Equal(t, valuesOf(name), []interface{}{"ann", "ann", "bob", "bobby"})
+}
+
+func TestHashJoinLeft(t *testing.T) {
+	orders, customers := ordersAndCustomers(t)
+
+	matches, err := HashJoin(orders, "customer", customers, "customer", LeftJoin)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, len(matches), 4)
+	assert.Equal(t, matches[2], Match{LeftIndex: 3, LeftLength: 2})
+	assert.Equal(t, matches[3], Match{LeftIndex: 5, LeftLength: 1}, "Expected nil keys not to match")
+
+	joined, err := Materialise(orders, customers, matches)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, joined.RowCount(), uint(7))
+	amount, _ := joined.Column("amount")
+	name, _ := joined.Column("name")
+	assert.Equal(t, valuesOf(amount), []interface{}{10, 20, 30, 30, 40, 50, 60})
+	assert.Equal(t, valuesOf(name), []interface{}{"ann", "ann", "bob", "bobby", nil, nil, nil})
+}
+
+func TestHashJoinMissingColumn(t *testing.T) {
+	orders, customers := ordersAndCustomers(t)
+	_, err := HashJoin(orders, "missing", customers, "customer", InnerJoin)
+	assert.Equal(t, err, ErrColumnNotFound)
+	_, err = HashJoin(orders, "customer", customers, "missing", InnerJoin)
+	assert.Equal(t, err, ErrColumnNotFound)
+}
+
+func TestMatchRows(t *testing.T) {
+	assert.Equal(t, Match{LeftLength: 3, RightLength: 4}.Rows(), uint(12))
+	assert.Equal(t, Match{LeftLength: 3}.Rows(), uint(3))
+}
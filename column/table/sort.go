@@ -0,0 +1,174 @@
+package table
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/lummie/golib/column/block"
+	"github.com/lummie/golib/column/value"
+)
+
+var ErrNoSortKeys = errors.New("table: at least one sort key is required")
+
+// SortKey names a column to sort by and its direction
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// a range of rows over which every key column holds the same values
+type segment struct {
+	index  uint
+	length uint
+	keys   []interface{}
+}
+
+// a run captured from a Block
+type run struct {
+	length uint
+	value  interface{}
+}
+
+// returns the row permutation that sorts the table by the keys, where perm[i] is the row that belongs at row i
+// the sort is stable and values are ordered by value.Compare, so nil values sort first in ascending order
+// rather than comparing rows, the ranges over which all key columns are constant are sorted, so the cost depends on the
+// number of runs in the key columns rather than the number of rows
+func (r *Table) SortPermutation(keys ...SortKey) ([]uint, error) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.sortPermutationLocked(keys)
+}
+
+// rebuilds every column of the table in the order given by perm, where perm[i] is the row placed at row i
+// the table is left unchanged if perm is not a valid permutation of the rows
+func (r *Table) ApplyPermutation(perm []uint) error {
+	r.Lock()
+	defer r.Unlock()
+	return r.applyPermutationLocked(perm)
+}
+
+// sorts the table by the keys, rewriting every column in sorted order
+func (r *Table) Sort(keys ...SortKey) error {
+	r.Lock()
+	defer r.Unlock()
+
+	perm, err := r.sortPermutationLocked(keys)
+	if err != nil {
+		return err
+	}
+	return r.applyPermutationLocked(perm)
+}
+
+func (r *Table) sortPermutationLocked(keys []SortKey) ([]uint, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSortKeys
+	}
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Column
+	}
+	columns, err := r.columnsLocked(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns[1:] {
+		if column.RowCount() != columns[0].RowCount() {
+			return nil, ErrRowCount
+		}
+	}
+
+	segments := segments(columns)
+	var compareErr error
+	sort.SliceStable(segments, func(i, j int) bool {
+		for k, key := range keys {
+			c, err := value.Compare(segments[i].keys[k], segments[j].keys[k])
+			if err != nil {
+				if compareErr == nil {
+					compareErr = err
+				}
+				return false
+			}
+			if c != 0 {
+				return (c < 0) != key.Descending
+			}
+		}
+		return false
+	})
+	if compareErr != nil {
+		return nil, compareErr
+	}
+
+	perm := make([]uint, 0, columns[0].RowCount())
+	for _, s := range segments {
+		for row := s.index; row < s.index+s.length; row++ {
+			perm = append(perm, row)
+		}
+	}
+	return perm, nil
+}
+
+func (r *Table) applyPermutationLocked(perm []uint) error {
+	// build every column before replacing any so a failure leaves the table unchanged
+	permuted := make([]*block.Block, len(r.names))
+	for i, name := range r.names {
+		column, err := r.columns[name].ApplyPermutation(perm)
+		if err != nil {
+			return err
+		}
+		permuted[i] = column
+	}
+	for i, name := range r.names {
+		r.columns[name] = permuted[i]
+	}
+	return nil
+}
+
+// splits the rows of the columns into the ranges over which every column holds the same value
+// if the columns hold different numbers of rows the segments stop at the end of the shortest column
+func segments(columns []*block.Block) []segment {
+	runs := make([][]run, len(columns))
+	for i, column := range columns {
+		runs[i] = runsOf(column)
+	}
+
+	result := []segment{}
+	pos := make([]int, len(columns))   // current run in each column
+	used := make([]uint, len(columns)) // rows of the current run already in a segment
+	var row uint
+	for {
+		for i := range columns {
+			if pos[i] == len(runs[i]) {
+				return result
+			}
+		}
+
+		// the segment ends at whichever current run ends first
+		s := segment{index: row, keys: make([]interface{}, len(columns))}
+		for i := range columns {
+			rest := runs[i][pos[i]].length - used[i]
+			if i == 0 || rest < s.length {
+				s.length = rest
+			}
+			s.keys[i] = runs[i][pos[i]].value
+		}
+		result = append(result, s)
+
+		row += s.length
+		for i := range columns {
+			used[i] += s.length
+			if used[i] == runs[i][pos[i]].length {
+				pos[i]++
+				used[i] = 0
+			}
+		}
+	}
+}
+
+// captures the runs of a Block
+func runsOf(b *block.Block) []run {
+	result := []run{}
+	b.IterateRuns(func(index uint, length uint, value interface{}) {
+		result = append(result, run{length: length, value: value})
+	})
+	return result
+}
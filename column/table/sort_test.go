@@ -0,0 +1,97 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/value"
+)
+
+// creates a table of cities, with the row number in the id column to check stability
+func citiesTable(t *testing.T) *Table {
+	tbl := New()
+	assert.Nil(t, tbl.AddColumn("country", columnOf("uk", "fr", "uk", "fr", "uk", "de")))
+	assert.Nil(t, tbl.AddColumn("city", columnOf("leeds", "paris", "leeds", "lyon", "york", "bonn")))
+	assert.Nil(t, tbl.AddColumn("id", columnOf(0, 1, 2, 3, 4, 5)))
+	return tbl
+}
+
+func TestSortPermutationSingleKey(t *testing.T) {
+	tbl := citiesTable(t)
+
+	perm, err := tbl.SortPermutation(SortKey{Column: "country"})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, perm, []uint{5, 1, 3, 0, 2, 4}, "Expected rows with equal keys to keep their order")
+
+	perm, err = tbl.SortPermutation(SortKey{Column: "country", Descending: true})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, perm, []uint{0, 2, 4, 1, 3, 5})
+}
+
+func TestSortMultipleKeys(t *testing.T) {
+	tbl := citiesTable(t)
+
+	err := tbl.Sort(SortKey{Column: "country"}, SortKey{Column: "city", Descending: true})
+	assert.Nil(t, err, "Unexpected Error")
+
+	country, _ := tbl.Column("country")
+	city, _ := tbl.Column("city")
+	id, _ := tbl.Column("id")
+	assert.Equal(t, valuesOf(country), []interface{}{"de", "fr", "fr", "uk", "uk", "uk"})
+	assert.Equal(t, valuesOf(city), []interface{}{"bonn", "paris", "lyon", "york", "leeds", "leeds"})
+	assert.Equal(t, valuesOf(id), []interface{}{5, 1, 3, 4, 0, 2})
+
+	// sorting improves the compression of the key column
+	assert.Equal(t, country.BlockCount(), uint(3))
+}
+
+func TestSortKeepsValueIndex(t *testing.T) {
+	tbl := citiesTable(t)
+	country, _ := tbl.Column("country")
+	country.EnableValueIndex()
+
+	err := tbl.Sort(SortKey{Column: "country"})
+	assert.Nil(t, err, "Unexpected Error")
+	country, _ = tbl.Column("country")
+	assert.NotNil(t, country.ValueIndex(), "Expected the sorted column to keep its value index")
+	assert.Equal(t, country.ValueIndex().Eq("uk").RowCount(), uint(3))
+}
+
+func TestSortErrors(t *testing.T) {
+	tbl := citiesTable(t)
+
+	err := tbl.Sort()
+	assert.Equal(t, err, ErrNoSortKeys)
+	err = tbl.Sort(SortKey{Column: "missing"})
+	assert.Equal(t, err, ErrColumnNotFound)
+
+	assert.Nil(t, tbl.AddColumn("mixed", columnOf(1, "a", 2, "b", 3, "c")))
+	err = tbl.Sort(SortKey{Column: "mixed"})
+	assert.Equal(t, err, value.ErrNotComparable)
+
+	// failed sorts leave the table unchanged
+	id, _ := tbl.Column("id")
+	assert.Equal(t, valuesOf(id), []interface{}{0, 1, 2, 3, 4, 5})
+}
+
+func TestApplyPermutation(t *testing.T) {
+	tbl := citiesTable(t)
+
+	err := tbl.ApplyPermutation([]uint{5, 4, 3, 2, 1, 0})
+	assert.Nil(t, err, "Unexpected Error")
+	id, _ := tbl.Column("id")
+	assert.Equal(t, valuesOf(id), []interface{}{5, 4, 3, 2, 1, 0})
+
+	err = tbl.ApplyPermutation([]uint{0, 1})
+	assert.NotNil(t, err, "Expected an error for an invalid permutation")
+	id, _ = tbl.Column("id")
+	assert.Equal(t, valuesOf(id), []interface{}{5, 4, 3, 2, 1, 0})
+}
+
+func TestSortEmptyTable(t *testing.T) {
+	tbl := New()
+	assert.Nil(t, tbl.AddColumn("empty", columnOf()))
+	perm, err := tbl.SortPermutation(SortKey{Column: "empty"})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, len(perm), 0)
+}
@@ -0,0 +1,94 @@
+package table
+
+// Implements a table of named block.Block columns
+// Every column in a table holds the same number of rows, row i of the table being row i of each column
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/lummie/golib/column/block"
+)
+
+var (
+	ErrColumnExists   = errors.New("table: column already exists")
+	ErrColumnNotFound = errors.New("table: column not found")
+	ErrRowCount       = errors.New("table: column row count does not match the table")
+)
+
+type Table struct {
+	sync.RWMutex
+	names   []string                // column names in the order they were added
+	columns map[string]*block.Block // columns by name
+}
+
+// Creates a new empty Table
+func New() *Table {
+	return &Table{
+		names:   []string{},
+		columns: make(map[string]*block.Block),
+	}
+}
+
+// adds a column to the table
+// returns ErrColumnExists if the name is in use or ErrRowCount if the column does not have the same row count as the
+// columns already in the table
+func (r *Table) AddColumn(name string, column *block.Block) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, found := r.columns[name]; found {
+		return ErrColumnExists
+	}
+	if len(r.names) > 0 && r.columns[r.names[0]].RowCount() != column.RowCount() {
+		return ErrRowCount
+	}
+
+	r.names = append(r.names, name)
+	r.columns[name] = column
+	return nil
+}
+
+// returns the named column
+func (r *Table) Column(name string) (*block.Block, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	column, found := r.columns[name]
+	if !found {
+		return nil, ErrColumnNotFound
+	}
+	return column, nil
+}
+
+// returns the column names in the order they were added
+func (r *Table) Names() []string {
+	r.RLock()
+	defer r.RUnlock()
+	return append([]string{}, r.names...)
+}
+
+// returns the number of rows in the table
+func (r *Table) RowCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.names) == 0 {
+		return 0
+	}
+	return r.columns[r.names[0]].RowCount()
+}
+
+// returns the columns for the names, or ErrColumnNotFound if any do not exist
+// the caller must hold the lock
+func (r *Table) columnsLocked(names []string) ([]*block.Block, error) {
+	result := make([]*block.Block, len(names))
+	for i, name := range names {
+		column, found := r.columns[name]
+		if !found {
+			return nil, ErrColumnNotFound
+		}
+		result[i] = column
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/block"
+)
+
+// creates a column holding the values in order
+func columnOf(values ...interface{}) *block.Block {
+	column := block.New(len(values))
+	for _, v := range values {
+		column.Append(v)
+	}
+	return column
+}
+
+// collects the values stored in the column in row order
+func valuesOf(column *block.Block) []interface{} {
+	result := []interface{}{}
+	column.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestAddColumn(t *testing.T) {
+	tbl := New()
+	assert.Equal(t, tbl.RowCount(), uint(0))
+
+	err := tbl.AddColumn("name", columnOf("a", "b", "c"))
+	assert.Nil(t, err, "Unexpected Error")
+	err = tbl.AddColumn("age", columnOf(1, 2, 3))
+	assert.Nil(t, err, "Unexpected Error")
+
+	assert.Equal(t, tbl.Names(), []string{"name", "age"})
+	assert.Equal(t, tbl.RowCount(), uint(3))
+
+	err = tbl.AddColumn("name", columnOf("x", "y", "z"))
+	assert.Equal(t, err, ErrColumnExists)
+	err = tbl.AddColumn("short", columnOf(1, 2))
+	assert.Equal(t, err, ErrRowCount)
+
+	column, err := tbl.Column("age")
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, valuesOf(column), []interface{}{1, 2, 3})
+	_, err = tbl.Column("missing")
+	assert.Equal(t, err, ErrColumnNotFound)
+}
@@ -0,0 +1,168 @@
+package value
+
+// Provides ordering and numeric conversion for the interface{} values stored in columns
+// Numbers of any kind compare numerically with each other, nil sorts before every other value
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotComparable = errors.New("value: values are not comparable")
+	ErrNotNumeric    = errors.New("value: value is not numeric")
+)
+
+// Compares a and b returning -1 if a < b, 0 if a == b and +1 if a > b
+// returns ErrNotComparable if the values are of types that have no common ordering
+func Compare(a, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, nil
+		case a == nil:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	}
+
+	// integers are compared without converting to float so large values keep their precision
+	ai, aSigned, aIsInt := integer(a)
+	bi, bSigned, bIsInt := integer(b)
+	if aIsInt && bIsInt {
+		return compareIntegers(ai, aSigned, bi, bSigned), nil
+	}
+
+	af, aIsNum := Float64(a)
+	bf, bIsNum := Float64(b)
+	if aIsNum && bIsNum {
+		return compareFloats(af, bf), nil
+	}
+
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), nil
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, nil
+			case bv:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv), nil
+		}
+	}
+	return 0, ErrNotComparable
+}
+
+// Converts a numeric value to a float64, returning false if the value is not numeric
+func Float64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	i, signed, ok := integer(v)
+	if !ok {
+		return 0, false
+	}
+	if signed {
+		return float64(int64(i)), true
+	}
+	return float64(i), true
+}
+
+// Converts an integer value to an int64, returning false if the value is not an integer or does not fit in an int64
+func Int64(v interface{}) (int64, bool) {
+	i, signed, ok := integer(v)
+	if !ok || (!signed && i > math.MaxInt64) {
+		return 0, false
+	}
+	return int64(i), true
+}
+
+// returns true if v is one of the integer kinds
+func IsInteger(v interface{}) bool {
+	_, _, ok := integer(v)
+	return ok
+}
+
+// returns the bits of an integer value and whether it is signed
+func integer(v interface{}) (uint64, bool, bool) {
+	switch n := v.(type) {
+	case int:
+		return uint64(n), true, true
+	case int8:
+		return uint64(n), true, true
+	case int16:
+		return uint64(n), true, true
+	case int32:
+		return uint64(n), true, true
+	case int64:
+		return uint64(n), true, true
+	case uint:
+		return uint64(n), false, true
+	case uint8:
+		return uint64(n), false, true
+	case uint16:
+		return uint64(n), false, true
+	case uint32:
+		return uint64(n), false, true
+	case uint64:
+		return n, false, true
+	}
+	return 0, false, false
+}
+
+func compareIntegers(a uint64, aSigned bool, b uint64, bSigned bool) int {
+	// a negative signed value is always less than any unsigned value
+	aNegative := aSigned && int64(a) < 0
+	bNegative := bSigned && int64(b) < 0
+	switch {
+	case aNegative && !bNegative:
+		return -1
+	case !aNegative && bNegative:
+		return 1
+	case aNegative && bNegative:
+		return compareInt64(int64(a), int64(b))
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
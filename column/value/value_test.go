@@ -0,0 +1,73 @@
+package value
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestCompareNumbers(t *testing.T) {
+	c, err := Compare(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, c, -1)
+
+	c, _ = Compare(uint8(7), int64(7))
+	assert.Equal(t, c, 0, "Expected integers of different kinds to compare numerically")
+
+	c, _ = Compare(-1, uint(0))
+	assert.Equal(t, c, -1, "Expected negative values to sort before unsigned values")
+
+	c, _ = Compare(uint64(math.MaxUint64), int64(math.MaxInt64))
+	assert.Equal(t, c, 1)
+
+	c, _ = Compare(2.5, 2)
+	assert.Equal(t, c, 1, "Expected floats and integers to compare numerically")
+}
+
+func TestCompareOtherTypes(t *testing.T) {
+	c, err := Compare("apple", "banana")
+	assert.Nil(t, err)
+	assert.Equal(t, c, -1)
+
+	c, _ = Compare(true, false)
+	assert.Equal(t, c, 1)
+
+	now := time.Now()
+	c, _ = Compare(now, now.Add(time.Second))
+	assert.Equal(t, c, -1)
+
+	c, _ = Compare(nil, "a")
+	assert.Equal(t, c, -1, "Expected nil to sort first")
+	c, _ = Compare(nil, nil)
+	assert.Equal(t, c, 0)
+}
+
+func TestCompareIncompatibleTypes(t *testing.T) {
+	_, err := Compare("1", 1)
+	assert.Equal(t, err, ErrNotComparable)
+
+	_, err = Compare(struct{}{}, struct{}{})
+	assert.Equal(t, err, ErrNotComparable)
+}
+
+func TestNumericConversion(t *testing.T) {
+	f, ok := Float64(int16(-3))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, f, -3.0)
+
+	_, ok = Float64("3")
+	assert.Equal(t, ok, false)
+
+	i, ok := Int64(uint32(9))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, i, int64(9))
+
+	_, ok = Int64(uint64(math.MaxUint64))
+	assert.Equal(t, ok, false, "Expected out of range unsigned values to fail")
+
+	_, ok = Int64(1.0)
+	assert.Equal(t, ok, false)
+	assert.Equal(t, IsInteger(uint(1)), true)
+}
@@ -0,0 +1,412 @@
+package wal
+
+// Implements an append-only write-ahead log for a block.Block
+// Every mutation is written to the log as a checksummed record before it is applied to the Block, so the Block can be
+// rebuilt after a crash from the last snapshot (a full Block.Write) plus the records logged since that snapshot.
+// The log is periodically compacted into a new snapshot to keep replay times short.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lummie/golib/column/block"
+)
+
+const (
+	snapshotFileName = "snapshot.dat"     // the last compacted Block
+	snapshotTempName = "snapshot.dat.tmp" // snapshot being written, renamed over snapshotFileName when complete
+	logFileName      = "wal.log"          // records appended since the last snapshot
+
+	recordHeaderSize = 8       // uint32 payload length + uint32 crc32 of the payload
+	maxRecordSize    = 1 << 26 // records larger than this are treated as corrupt
+)
+
+// operation codes stored in each record
+const (
+	OpAppend uint8 = iota + 1 // appends Value to the Block
+)
+
+var (
+	ErrUnknownOp = errors.New("wal: record contains an unknown operation")
+	ErrClosed    = errors.New("wal: log is closed")
+)
+
+// the operations used on the log file, satisfied by *os.File
+type logFile interface {
+	io.WriteCloser
+	io.Seeker
+	Sync() error
+	Truncate(size int64) error
+}
+
+// a single logged mutation
+type record struct {
+	LSN   uint64      // log sequence number, increases by one for every record
+	Op    uint8       // operation code
+	Value interface{} // operand of the operation
+}
+
+type Log struct {
+	sync.Mutex
+	dir          string
+	block        *block.Block
+	file         logFile
+	size         int64  // size of the log file up to the end of the last record written
+	lsn          uint64 // sequence number of the last record written
+	failed       error  // set if a failed write could not be rolled back, returned by every later write
+	records      uint   // number of records written since the last snapshot
+	compactEvery uint   // number of records after which the log is compacted, 0 disables automatic compaction
+}
+
+// Opens the log stored in dir, creating the directory if needed, and replays it into a new Block
+// compactEvery is the number of appended records after which the log is automatically compacted into a new snapshot,
+// 0 disables automatic compaction
+func Open(dir string, compactEvery uint) (*Log, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, lsn, records, validSize, err := replay(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// discard any torn or corrupt records at the tail so new records follow the last valid one
+	err = file.Truncate(validSize)
+	if err == nil {
+		_, err = file.Seek(validSize, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Log{
+		dir:          dir,
+		block:        blk,
+		file:         file,
+		size:         validSize,
+		lsn:          lsn,
+		records:      records,
+		compactEvery: compactEvery,
+	}, nil
+}
+
+// returns the Block maintained by the log
+// the Block must only be mutated through the log, otherwise the changes are not durable
+func (r *Log) Block() *block.Block {
+	return r.block
+}
+
+// logs the append of value and then appends it to the Block, returning the row index of the value
+// an error means the value was not appended. Once the value is durable a failed automatic compaction is not reported,
+// as the log is still valid, it is retried by the next Append and Compact can be called to retry it and see the error
+func (r *Log) Append(value interface{}) (uint, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	err := r.write(OpAppend, value)
+	if err != nil {
+		return 0, err
+	}
+	index := r.block.Append(value)
+
+	if r.compactEvery > 0 && r.records >= r.compactEvery {
+		// records is only reset by a successful compaction, so a failure is retried on the next append
+		r.compact()
+	}
+	return index, nil
+}
+
+// writes the current Block as a new snapshot and empties the log
+func (r *Log) Compact() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.compact()
+}
+
+// closes the log file, the Block remains usable but can no longer be appended to through the log
+func (r *Log) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.file == nil {
+		return ErrClosed
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// rebuilds the Block stored in dir from the last snapshot plus the records in the log
+// torn or corrupt records at the end of the log, as left by a crash part way through a write, are ignored
+func Replay(dir string) (*block.Block, error) {
+	blk, _, _, _, err := replay(dir)
+	return blk, err
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	RECORDS
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// encodes and writes a single record, syncing it to disk before returning
+// if the write or sync fails the log is truncated back to the end of the previous record, otherwise the torn record
+// would end replay and every record written after it would be lost
+func (r *Log) write(op uint8, value interface{}) error {
+	if r.file == nil {
+		return ErrClosed
+	}
+	if r.failed != nil {
+		return r.failed
+	}
+
+	payload := new(bytes.Buffer)
+	err := gob.NewEncoder(payload).Encode(&record{
+		LSN:   r.lsn + 1,
+		Op:    op,
+		Value: value,
+	})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+payload.Len())
+	binary.BigEndian.PutUint32(buf[0:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	buf = append(buf, payload.Bytes()...)
+
+	_, err = r.file.Write(buf)
+	if err == nil {
+		err = r.file.Sync()
+	}
+	if err != nil {
+		r.rollback()
+		return err
+	}
+
+	r.size += int64(len(buf))
+	r.lsn++
+	r.records++
+	return nil
+}
+
+// discards anything written after the last record, if that fails the log can't be trusted and later writes fail
+func (r *Log) rollback() {
+	err := r.file.Truncate(r.size)
+	if err == nil {
+		_, err = r.file.Seek(r.size, io.SeekStart)
+	}
+	if err == nil {
+		err = r.file.Sync()
+	}
+	if err != nil {
+		r.failed = err
+	}
+}
+
+// reads the next record from reader
+// returns io.EOF when there are no more valid records, which includes a torn or corrupt record at the tail
+func readRecord(reader io.Reader) (*record, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return nil, 0, io.EOF
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if size > maxRecordSize {
+		return nil, 0, io.EOF
+	}
+
+	payload := make([]byte, size)
+	_, err = io.ReadFull(reader, payload)
+	if err != nil || crc32.ChecksumIEEE(payload) != checksum {
+		return nil, 0, io.EOF
+	}
+
+	rec := &record{}
+	err = gob.NewDecoder(bytes.NewReader(payload)).Decode(rec)
+	if err != nil {
+		return nil, 0, io.EOF
+	}
+	return rec, int64(recordHeaderSize) + int64(size), nil
+}
+
+// applies the record to the Block
+func apply(blk *block.Block, rec *record) error {
+	switch rec.Op {
+	case OpAppend:
+		blk.Append(rec.Value)
+	default:
+		return ErrUnknownOp
+	}
+	return nil
+}
+
+/*
+----------------------------------------------------------------------------------------------------------------------------------------
+	SNAPSHOTS and REPLAY
+----------------------------------------------------------------------------------------------------------------------------------------
+*/
+
+// writes the Block to a temporary snapshot which is then renamed over the previous one, finally truncating the log
+// the snapshot records the LSN it includes so a crash between the rename and the truncate does not replay records twice
+func (r *Log) compact() error {
+	if r.file == nil {
+		return ErrClosed
+	}
+
+	tempName := filepath.Join(r.dir, snapshotTempName)
+	fo, err := os.Create(tempName)
+	if err != nil {
+		return err
+	}
+
+	err = writeSnapshot(fo, r.lsn, r.block)
+	if err == nil {
+		err = fo.Sync()
+	}
+	if closeErr := fo.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	err = os.Rename(tempName, filepath.Join(r.dir, snapshotFileName))
+	if err != nil {
+		return err
+	}
+	// the rename must be durable before the log is truncated, otherwise a crash could keep the truncate but lose the
+	// rename, losing every record since the previous snapshot
+	err = syncDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	err = r.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	r.size = 0
+	r.records = 0
+	return nil
+}
+
+// syncs the directory so the files created and renamed in it are durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writes the snapshot header followed by the Block
+func writeSnapshot(writer io.Writer, lsn uint64, blk *block.Block) error {
+	w := bufio.NewWriter(writer)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint64(header, lsn)
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	err = blk.Write(w)
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// reads the snapshot in dir, returning an empty Block when no snapshot has been written yet
+func readSnapshot(dir string) (*block.Block, uint64, error) {
+	f, err := os.Open(filepath.Join(dir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return block.New(0), 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header := make([]byte, 8)
+	_, err = io.ReadFull(reader, header)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blk := block.New(0)
+	err = blk.Read(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return blk, binary.BigEndian.Uint64(header), nil
+}
+
+// rebuilds the Block from the snapshot and log in dir
+// returns the Block, the last LSN applied, the number of records in the log and the size of the valid part of the log
+func replay(dir string) (*block.Block, uint64, uint, int64, error) {
+	blk, lsn, err := readSnapshot(dir)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, logFileName))
+	if os.IsNotExist(err) {
+		return blk, lsn, 0, 0, nil
+	}
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var records uint
+	var validSize int64
+	for {
+		rec, size, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+
+		// records already included in the snapshot are skipped
+		if rec.LSN > lsn {
+			err = apply(blk, rec)
+			if err != nil {
+				return nil, 0, 0, 0, err
+			}
+			lsn = rec.LSN
+		}
+		records++
+		validSize += size
+	}
+
+	return blk, lsn, records, validSize, nil
+}
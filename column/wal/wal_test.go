@@ -0,0 +1,229 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column/block"
+)
+
+// collects the values stored in the block in row order
+func values(blk *block.Block) []interface{} {
+	result := []interface{}{}
+	blk.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestReplayEmptyDirectory(t *testing.T) {
+	blk, err := Replay(t.TempDir())
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{}, "Expected an empty block")
+}
+
+func TestAppendThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 0)
+	assert.Nil(t, err, "Unexpected Open Error")
+
+	for _, v := range []string{"Value 1", "Value 1", "Value 2", "Value 3"} {
+		_, err = log.Append(v)
+		assert.Nil(t, err, "Unexpected Append Error")
+	}
+	assert.Nil(t, log.Close(), "Unexpected Close Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1", "Value 1", "Value 2", "Value 3"})
+}
+
+func TestCompactionAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 3)
+	assert.Nil(t, err, "Unexpected Open Error")
+
+	for i := 0; i < 10; i++ {
+		index, err := log.Append(i / 2)
+		assert.Nil(t, err, "Unexpected Append Error")
+		assert.Equal(t, index, uint(i))
+	}
+	assert.Nil(t, log.Close(), "Unexpected Close Error")
+
+	// 9 records have been compacted, leaving 1 in the log
+	info, err := os.Stat(filepath.Join(dir, snapshotFileName))
+	assert.Nil(t, err, "Expected a snapshot to have been written")
+	assert.Equal(t, info.Size() > 0, true)
+
+	log, err = Open(dir, 3)
+	assert.Nil(t, err, "Unexpected Open Error")
+	assert.Equal(t, log.records, uint(1), "Expected one record after the last compaction")
+	_, err = log.Append(5)
+	assert.Nil(t, err, "Unexpected Append Error")
+	assert.Nil(t, log.Close(), "Unexpected Close Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5})
+}
+
+func TestFailedCompactionDoesNotFailAppend(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 2)
+	assert.Nil(t, err, "Unexpected Open Error")
+
+	// a directory in the way of the temporary snapshot makes every compaction fail
+	blocker := filepath.Join(dir, snapshotTempName)
+	assert.Nil(t, os.Mkdir(blocker, 0700), "Unexpected Mkdir Error")
+
+	for i := 0; i < 3; i++ {
+		index, err := log.Append(i)
+		assert.Nil(t, err, "Expected the append to succeed although compaction failed")
+		assert.Equal(t, index, uint(i))
+	}
+	assert.Equal(t, log.records, uint(3), "Expected the records to remain in the log")
+	assert.NotNil(t, log.Compact(), "Expected Compact to report the failure")
+
+	// the next append retries the compaction
+	assert.Nil(t, os.Remove(blocker), "Unexpected Remove Error")
+	_, err = log.Append(3)
+	assert.Nil(t, err, "Unexpected Append Error")
+	assert.Equal(t, log.records, uint(0), "Expected the retried compaction to empty the log")
+	assert.Nil(t, log.Close(), "Unexpected Close Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{0, 1, 2, 3})
+}
+
+func TestReplayIgnoresTornTail(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 0)
+	assert.Nil(t, err, "Unexpected Open Error")
+	log.Append("Value 1")
+	log.Append("Value 2")
+	log.Close()
+
+	// simulate a crash part way through writing the second record
+	logName := filepath.Join(dir, logFileName)
+	info, _ := os.Stat(logName)
+	assert.Nil(t, os.Truncate(logName, info.Size()-3), "Unexpected Truncate Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1"})
+
+	// reopening discards the torn record so new records are readable
+	log, err = Open(dir, 0)
+	assert.Nil(t, err, "Unexpected Open Error")
+	log.Append("Value 3")
+	log.Close()
+
+	blk, err = Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1", "Value 3"})
+}
+
+var errDiskFull = errors.New("disk full")
+
+// a log file whose next Write stores only the first few bytes and then fails, or whose next Sync fails
+type failingFile struct {
+	*os.File
+	failWrite bool
+	failSync  bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if f.failWrite {
+		f.failWrite = false
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, errDiskFull
+	}
+	return f.File.Write(p)
+}
+
+func (f *failingFile) Sync() error {
+	if f.failSync {
+		f.failSync = false
+		return errDiskFull
+	}
+	return f.File.Sync()
+}
+
+func TestFailedWriteIsRolledBack(t *testing.T) {
+	dir := t.TempDir()
+	log, err := Open(dir, 0)
+	assert.Nil(t, err, "Unexpected Open Error")
+	file := &failingFile{File: log.file.(*os.File)}
+	log.file = file
+
+	_, err = log.Append("Value 1")
+	assert.Nil(t, err, "Unexpected Append Error")
+	file.failWrite = true
+	_, err = log.Append("Value 2")
+	assert.Equal(t, err, errDiskFull)
+	file.failSync = true
+	_, err = log.Append("Value 3")
+	assert.Equal(t, err, errDiskFull)
+	assert.Equal(t, values(log.Block()), []interface{}{"Value 1"}, "Expected failed appends not to reach the Block")
+
+	// records written after the failures must survive replay and reopening
+	_, err = log.Append("Value 4")
+	assert.Nil(t, err, "Unexpected Append Error")
+	log.Close()
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1", "Value 4"})
+
+	log, err = Open(dir, 0)
+	assert.Nil(t, err, "Unexpected Open Error")
+	assert.Equal(t, values(log.Block()), []interface{}{"Value 1", "Value 4"})
+	log.Close()
+}
+
+func TestReplayIgnoresCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	log, _ := Open(dir, 0)
+	log.Append("Value 1")
+	log.Append("Value 2")
+	log.Close()
+
+	// flip the last byte of the second record's payload
+	logName := filepath.Join(dir, logFileName)
+	data, _ := os.ReadFile(logName)
+	data[len(data)-1] ^= 0xff
+	assert.Nil(t, os.WriteFile(logName, data, 0644), "Unexpected WriteFile Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1"})
+}
+
+func TestReplaySkipsRecordsIncludedInSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	log, _ := Open(dir, 0)
+	log.Append("Value 1")
+	log.Append("Value 2")
+
+	// keep a copy of the log to simulate a crash between the snapshot rename and the log truncate
+	logName := filepath.Join(dir, logFileName)
+	data, _ := os.ReadFile(logName)
+	assert.Nil(t, log.Compact(), "Unexpected Compact Error")
+	log.Close()
+	assert.Nil(t, os.WriteFile(logName, data, 0644), "Unexpected WriteFile Error")
+
+	blk, err := Replay(dir)
+	assert.Nil(t, err, "Unexpected Replay Error")
+	assert.Equal(t, values(blk), []interface{}{"Value 1", "Value 2"})
+}
+
+func TestAppendAfterClose(t *testing.T) {
+	log, _ := Open(t.TempDir(), 0)
+	log.Close()
+	_, err := log.Append("Value 1")
+	assert.Equal(t, err, ErrClosed)
+}
@@ -0,0 +1,13 @@
+package rlelist
+
+import "github.com/lummie/golib/column"
+
+// creates an RleList holding the same rows as b, copying it run by run
+// b is usually a *block.Block, but any column.Column can be converted
+func FromBlock(b column.Column) *RleList {
+	result := New()
+	b.IterateRuns(func(index uint, length uint, value interface{}) {
+		result.AppendRun(value, length)
+	})
+	return result
+}
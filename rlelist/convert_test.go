@@ -0,0 +1,20 @@
+package rlelist
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+	columnblock "github.com/lummie/golib/column/block"
+)
+
+func TestFromBlock(t *testing.T) {
+	b := columnblock.New(10)
+	b.AppendMany("a", "a", nil, 3)
+	b.AppendRun(3, 1000)
+
+	list := FromBlock(b)
+	assert.Equal(t, list.RowCount(), uint(1004))
+	assert.Equal(t, list.BlockCount(), uint(3))
+	assert.Equal(t, listValues(list)[:4], []interface{}{"a", "a", nil, 3})
+	checkBlocks(t, list)
+}
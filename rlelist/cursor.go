@@ -0,0 +1,181 @@
+package rlelist
+
+import "container/list"
+
+// Cursor is a position within the rows of an RleList
+// it moves block by block, keeping the current block and the offset of the row within it, so no rows are expanded.
+// A new Cursor is not on a row, Next moves it to the first row and Prev to the last, so rows can be walked in either order.
+// The read lock is taken for each move. Rows appended while the Cursor is in use are seen by it, even once Next has
+// moved past the last row, and if rows are inserted, removed or the list is read the Cursor finds its row again by position.
+type Cursor struct {
+	list    *RleList
+	element *list.Element // element of the current block, nil when not positioned on a row
+	offset  uint          // offset of the current row within the block
+	row     uint          // index of the current row, or the row count when the cursor moved past the last row
+	after   bool          // true once the cursor has moved past the last row
+	moved   bool          // false until the cursor first moves, so Prev starts from the last row
+	version uint64        // version of the RleList when the cursor last moved
+}
+
+// returns a Cursor over the rows of the RleList
+func (r *RleList) Cursor() *Cursor {
+	r.RLock()
+	defer r.RUnlock()
+	return &Cursor{list: r, version: r.version}
+}
+
+// moves to the next row, returning false if there are no more rows
+func (r *Cursor) Next() bool {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	r.moved = true
+
+	switch {
+	case r.after:
+		// move onto any rows appended since the cursor moved past the last row
+		if r.row >= r.list.rowCount {
+			return false
+		}
+		return r.seekLocked(r.row)
+	case r.element == nil:
+		r.element = r.list.list.Front()
+		r.offset = 0
+	case r.offset+1 < r.block().Length:
+		r.offset++
+	default:
+		r.element = r.element.Next()
+		r.offset = 0
+	}
+
+	if r.element == nil {
+		r.after = true
+		r.row = r.list.rowCount
+		return false
+	}
+	r.row = r.block().RowIndex + r.offset
+	return true
+}
+
+// moves to the previous row, returning false if there are no earlier rows
+func (r *Cursor) Prev() bool {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	if !r.moved {
+		r.moved = true
+		r.after = true
+	}
+
+	switch {
+	case r.element == nil && !r.after:
+		return false
+	case r.element == nil:
+		r.element = r.list.list.Back()
+		r.after = false
+		if r.element != nil {
+			r.offset = r.block().Length - 1
+		}
+	case r.offset > 0:
+		r.offset--
+	default:
+		r.element = r.element.Prev()
+		if r.element != nil {
+			r.offset = r.block().Length - 1
+		}
+	}
+
+	if r.element == nil {
+		return false
+	}
+	r.row = r.block().RowIndex + r.offset
+	return true
+}
+
+// moves to row, returning false and moving past the last row if the row is not stored
+func (r *Cursor) Seek(row uint) bool {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.version = r.list.version
+	r.moved = true
+	return r.seekLocked(row)
+}
+
+// returns true if the cursor is positioned on a row
+func (r *Cursor) Valid() bool {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	return r.element != nil
+}
+
+// returns the index of the current row, or 0 if the cursor is not positioned on a row
+func (r *Cursor) Row() uint {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	if r.element == nil {
+		return 0
+	}
+	return r.row
+}
+
+// returns the value of the current row, or nil if the cursor is not positioned on a row
+func (r *Cursor) Value() interface{} {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	if r.element == nil {
+		return nil
+	}
+	return r.block().Value
+}
+
+// returns the block holding the current row as a Run, or an empty Run if the cursor is not positioned on a row
+func (r *Cursor) Run() Run {
+	r.list.RLock()
+	defer r.list.RUnlock()
+	r.syncLocked()
+	if r.element == nil {
+		return Run{}
+	}
+	b := r.block()
+	return Run{Index: b.RowIndex, Length: b.Length, Value: b.Value}
+}
+
+// returns the current block
+func (r *Cursor) block() *block {
+	return r.element.Value.(*block)
+}
+
+// finds the current row again if the blocks have been edited since the cursor last moved
+// the caller must hold the read lock
+func (r *Cursor) syncLocked() {
+	if r.version == r.list.version {
+		return
+	}
+	r.version = r.list.version
+	if r.element != nil {
+		r.seekLocked(r.row)
+	} else if r.after && r.row > r.list.rowCount {
+		// rows were removed while the cursor was past the last row, so it is now past the new last row
+		r.row = r.list.rowCount
+	}
+}
+
+// moves to row, the caller must hold the read lock
+func (r *Cursor) seekLocked(row uint) bool {
+	if row >= r.list.rowCount {
+		r.element = nil
+		r.offset = 0
+		r.row = r.list.rowCount
+		r.after = true
+		return false
+	}
+
+	r.element = r.list.elementLocked(row)
+	r.offset = row - r.block().RowIndex
+	r.row = row
+	r.after = false
+	return true
+}
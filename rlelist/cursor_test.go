@@ -0,0 +1,136 @@
+package rlelist
+
+import (
+	"testing"
+
+	"github.com/lummie/golib/assert"
+)
+
+func TestIterateReverse(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "a", "b", "c")
+
+	indexes := []uint{}
+	values := []interface{}{}
+	list.IterateReverse(func(index uint, value interface{}) {
+		indexes = append(indexes, index)
+		values = append(values, value)
+	})
+	assert.Equal(t, indexes, []uint{3, 2, 1, 0})
+	assert.Equal(t, values, []interface{}{"c", "b", "a", "a"})
+}
+
+func TestCursorNextAndPrev(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "a", "b")
+	list.AppendRun("c", 3)
+
+	c := list.Cursor()
+	assert.Equal(t, c.Valid(), false, "Expected a new cursor not to be on a row")
+	values := []interface{}{}
+	for c.Next() {
+		assert.Equal(t, c.Row(), uint(len(values)))
+		values = append(values, c.Value())
+	}
+	assert.Equal(t, values, []interface{}{"a", "a", "b", "c", "c", "c"})
+	assert.Equal(t, c.Next(), false)
+	assert.Equal(t, c.Value(), nil)
+
+	// moving back from past the last row walks the rows in reverse
+	values = []interface{}{}
+	for c.Prev() {
+		values = append(values, c.Value())
+	}
+	assert.Equal(t, values, []interface{}{"c", "c", "c", "b", "a", "a"})
+	assert.Equal(t, c.Prev(), false)
+
+	latest := list.Cursor()
+	assert.Equal(t, latest.Prev(), true, "Expected Prev on a new cursor to move to the last row")
+	assert.Equal(t, latest.Row(), uint(5))
+}
+
+func TestCursorSeek(t *testing.T) {
+	list := New()
+	list.AppendRun("a", 1000)
+	list.AppendRun("b", 1000)
+
+	c := list.Cursor()
+	assert.Equal(t, c.Seek(1500), true)
+	assert.Equal(t, c.Value(), "b")
+	assert.Equal(t, c.Run(), Run{Index: 1000, Length: 1000, Value: "b"})
+
+	assert.Equal(t, c.Seek(1000), true)
+	assert.Equal(t, c.Prev(), true)
+	assert.Equal(t, c.Row(), uint(999))
+	assert.Equal(t, c.Value(), "a")
+
+	assert.Equal(t, c.Seek(2000), false)
+	assert.Equal(t, c.Valid(), false)
+	assert.Equal(t, c.Prev(), true)
+	assert.Equal(t, c.Row(), uint(1999))
+}
+
+func TestCursorEmptyList(t *testing.T) {
+	c := New().Cursor()
+	assert.Equal(t, c.Next(), false)
+	assert.Equal(t, c.Prev(), false)
+	assert.Equal(t, c.Seek(0), false)
+	assert.Equal(t, c.Run(), Run{})
+}
+
+func TestCursorFollowsEdits(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "b", "c", "d")
+
+	c := list.Cursor()
+	assert.Equal(t, c.Seek(2), true)
+	assert.Equal(t, c.Value(), "c")
+
+	// the cursor keeps its row position when blocks are inserted and removed
+	assert.Nil(t, list.InsertAt(0, "z"), "Unexpected Error")
+	assert.Equal(t, c.Row(), uint(2))
+	assert.Equal(t, c.Value(), "b")
+	assert.Nil(t, list.RemoveRange(1, 2), "Unexpected Error")
+	assert.Equal(t, c.Value(), "d")
+	assert.Equal(t, c.Next(), false)
+
+	// appended rows are seen by the cursor
+	list.Append("e")
+	assert.Equal(t, c.Prev(), true)
+	assert.Equal(t, c.Value(), "e")
+
+	assert.Nil(t, list.RemoveRange(0, 3), "Unexpected Error")
+	assert.Equal(t, c.Valid(), false, "Expected the cursor to move past the last row once its row was removed")
+}
+
+func TestCursorNextAfterAppendPastLastRow(t *testing.T) {
+	list := New()
+	list.Append("a")
+
+	c := list.Cursor()
+	assert.Equal(t, c.Next(), true)
+	assert.Equal(t, c.Next(), false)
+
+	// rows appended after the cursor moved past the last row are visited in order
+	list.Append("b")
+	list.AppendRun("c", 2)
+	values := []interface{}{}
+	for c.Next() {
+		values = append(values, c.Value())
+	}
+	assert.Equal(t, values, []interface{}{"b", "c", "c"})
+	assert.Equal(t, c.Next(), false)
+
+	// likewise for a cursor that sought past the last row and one over an empty list
+	assert.Equal(t, c.Seek(10), false)
+	list.Append("d")
+	assert.Equal(t, c.Next(), true)
+	assert.Equal(t, c.Row(), uint(4))
+
+	empty := New()
+	c = empty.Cursor()
+	assert.Equal(t, c.Next(), false)
+	empty.Append("a")
+	assert.Equal(t, c.Next(), true)
+	assert.Equal(t, c.Value(), "a")
+}
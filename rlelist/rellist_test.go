@@ -3,11 +3,18 @@ package rlelist
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/gob"
 	"github.com/lummie/golib/assert"
+	"github.com/lummie/golib/column"
+	"github.com/lummie/golib/column/columntest"
+	"io"
 	"math/rand"
 	"os"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestThatBlockAndRowCountAreCorrectForAppend(t *testing.T) {
@@ -130,6 +137,435 @@ func TestIteratorReadWriteWithItemsInManyBlocks(t *testing.T) {
 
 }
 
+func TestAppendMany(t *testing.T) {
+	list := New()
+	list.Append("Value 1")
+
+	index := list.AppendMany("Value 1", "Value 2", "Value 2", "Value 3")
+	assert.Equal(t, index, uint(1), "Expected the row index of the first value")
+	assert.Equal(t, list.rowCount, uint(5))
+	assert.Equal(t, list.blockCount, uint(3))
+
+	index = list.AppendMany()
+	assert.Equal(t, index, uint(5), "Expected the row count when there are no values")
+}
+
+func TestAppendRun(t *testing.T) {
+	list := New()
+	index := list.AppendRun("Value 1", 1000000)
+	assert.Equal(t, index, uint(0))
+
+	index = list.AppendRun("Value 1", 5)
+	assert.Equal(t, index, uint(1000000), "Expected the row index of the first row of the run")
+	assert.Equal(t, list.blockCount, uint(1), "Expected an equal value to extend the last block")
+
+	index = list.AppendRun("Value 2", 2)
+	assert.Equal(t, index, uint(1000005))
+	index = list.AppendRun("Value 3", 0)
+	assert.Equal(t, index, uint(1000007), "Expected the row count when count is 0")
+
+	assert.Equal(t, list.rowCount, uint(1000007))
+	assert.Equal(t, list.blockCount, uint(2))
+	checkBlocks(t, list)
+}
+
+// checks the blocks are contiguous, that adjacent blocks differ and that the counts agree with the blocks
+func checkBlocks(t *testing.T, list *RleList) {
+	var rows uint
+	var previous *block
+	for listItem := list.list.Front(); listItem != nil; listItem = listItem.Next() {
+		b := listItem.Value.(*block)
+		if b.RowIndex != rows {
+			t.Error("Block starts at row", b.RowIndex, "expected", rows)
+		}
+		if previous != nil && previous.Value == b.Value {
+			t.Error("Adjacent blocks hold the same value", b.Value)
+		}
+		rows += b.Length
+		previous = b
+	}
+	assert.Equal(t, list.rowCount, rows, "Expected the row count to match the blocks")
+	assert.Equal(t, list.blockCount, uint(list.list.Len()), "Expected the block count to match the blocks")
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	const writers = 8
+	const rowsPerWriter = 2000
+
+	list := New()
+	// half the iterations append one row, the other half three
+	total := writers * (rowsPerWriter/2 + rowsPerWriter/2*3)
+	indexes := make(chan uint, total)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rowsPerWriter; i++ {
+				if i%2 == 0 {
+					indexes <- list.Append(w % 2)
+				} else {
+					first := list.AppendMany(w, w, i)
+					indexes <- first
+					indexes <- first + 1
+					indexes <- first + 2
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(indexes)
+
+	// every row index is returned exactly once
+	seen := make([]bool, total)
+	for index := range indexes {
+		if index >= uint(total) || seen[index] {
+			t.Fatal("Row index returned more than once or out of range", index)
+		}
+		seen[index] = true
+	}
+	assert.Equal(t, list.rowCount, uint(total))
+	checkBlocks(t, list)
+}
+
+func TestIterateContextCancelled(t *testing.T) {
+	list := New()
+	list.AppendRun("Value", 100000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := list.IterateContext(ctx, func(index uint, value interface{}) {
+		cancel()
+		count++
+	})
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, count, cancelCheckInterval, "Expected the iteration to stop within the long block")
+
+	count = 0
+	err = list.IterateContext(context.Background(), func(index uint, value interface{}) {
+		count++
+	})
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, count, 100000)
+}
+
+func TestReadContextCancelled(t *testing.T) {
+	list := New()
+	list.AppendMany("Value 1", "Value 2", "Value 3")
+
+	buf := new(bytes.Buffer)
+	err := list.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	read := New()
+	err = read.ReadContext(ctx, bytes.NewReader(buf.Bytes()))
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, read.rowCount, uint(0), "Expected a cancelled read to leave the list empty")
+	assert.Equal(t, read.list.Len(), 0)
+
+	err = read.ReadContext(context.Background(), bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, read.rowCount, uint(3))
+	checkBlocks(t, read)
+}
+
+// encodes a stream in the RLELIST layout from the parts given, which need not be valid
+func encodeStream(t *testing.T, typeTag string, rowCount uint, blockCount uint, blocks ...*block) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	enc := gob.NewEncoder(buf)
+	for _, v := range []interface{}{typeTag, rowCount, blockCount} {
+		assert.Nil(t, enc.Encode(v), "Unexpected Encode Error")
+	}
+	for _, b := range blocks {
+		assert.Nil(t, enc.Encode(b), "Unexpected Encode Error")
+	}
+	return buf
+}
+
+func TestReadValidatesStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   *bytes.Buffer
+		expected error
+	}{
+		{"wrong type tag", encodeStream(t, "RLEARRAY", 1, 1, &block{RowIndex: 0, Length: 1, Value: "a"}), ErrNotRleList},
+		{"more blocks than rows", encodeStream(t, "RLELIST", 1, 2), ErrInvalidBlockCount},
+		{"rows without blocks", encodeStream(t, "RLELIST", 5, 0), ErrInvalidBlockCount},
+		{"empty block", encodeStream(t, "RLELIST", 2, 2, &block{RowIndex: 0, Length: 0, Value: "a"}), ErrEmptyBlock},
+		{"gap between blocks", encodeStream(t, "RLELIST", 3, 2,
+			&block{RowIndex: 0, Length: 1, Value: "a"}, &block{RowIndex: 2, Length: 1, Value: "b"}), ErrBlockNotContiguous},
+		{"block beyond the row count", encodeStream(t, "RLELIST", 3, 2,
+			&block{RowIndex: 0, Length: 2, Value: "a"}, &block{RowIndex: 2, Length: 5, Value: "b"}), ErrRowCountMismatch},
+		{"blocks short of the row count", encodeStream(t, "RLELIST", 3, 1, &block{RowIndex: 0, Length: 2, Value: "a"}), ErrRowCountMismatch},
+		{"block count beyond the stream", encodeStream(t, "RLELIST", ^uint(0), ^uint(0)>>1, &block{RowIndex: 0, Length: 1, Value: "a"}), io.EOF},
+	}
+
+	for _, test := range tests {
+		list := New()
+		list.Append("Existing")
+		err := list.Read(test.stream)
+		assert.Equal(t, err, test.expected, test.name)
+		assert.Equal(t, list.rowCount, uint(0), "Expected the list to be emptied", test.name)
+		assert.Equal(t, list.list.Len(), 0, "Expected the list to be emptied", test.name)
+	}
+}
+
+func TestReadValidStream(t *testing.T) {
+	list := New()
+	err := list.Read(encodeStream(t, "RLELIST", 3, 2, &block{RowIndex: 0, Length: 2, Value: "a"}, &block{RowIndex: 2, Length: 1, Value: "b"}))
+	assert.Nil(t, err, "Unexpected Read Error")
+	assert.Equal(t, listValues(list), []interface{}{"a", "a", "b"})
+	checkBlocks(t, list)
+}
+
+func TestAll(t *testing.T) {
+	list := New()
+	list.AppendMany("Value 1", "Value 1", "Value 2")
+
+	values := []interface{}{}
+	for index, value := range list.All() {
+		assert.Equal(t, index, uint(len(values)))
+		values = append(values, value)
+	}
+	assert.Equal(t, values, []interface{}{"Value 1", "Value 1", "Value 2"})
+
+	count := 0
+	for range list.All() {
+		count++
+		break
+	}
+	assert.Equal(t, count, 1, "Expected break to stop the sequence")
+
+	// the lock is released once the loop has finished
+	list.Append("Value 3")
+	assert.Equal(t, list.rowCount, uint(4))
+}
+
+func TestRuns(t *testing.T) {
+	list := New()
+	list.AppendRun("Value 1", 3)
+	list.Append("Value 2")
+
+	runs := []Run{}
+	for run := range list.Runs() {
+		runs = append(runs, run)
+	}
+	assert.Equal(t, runs, []Run{{Index: 0, Length: 3, Value: "Value 1"}, {Index: 3, Length: 1, Value: "Value 2"}})
+}
+
+func TestGet(t *testing.T) {
+	list := New()
+	for i := 0; i < 1000; i++ {
+		list.Append(i / 3)
+	}
+
+	for row := uint(0); row < 1000; row++ {
+		value, err := list.Get(row)
+		assert.Nil(t, err, "Unexpected Error")
+		assert.Equal(t, value, int(row/3))
+	}
+
+	_, err := list.Get(1000)
+	assert.Equal(t, err, ErrRowOutOfRange)
+	_, err = New().Get(0)
+	assert.Equal(t, err, ErrRowOutOfRange)
+}
+
+func TestGetAfterRead(t *testing.T) {
+	list := New()
+	list.AppendMany("Value 1", "Value 2", "Value 2", "Value 3")
+	buf := new(bytes.Buffer)
+	err := list.Write(buf)
+	assert.Nil(t, err, "Unexpected Write Error")
+
+	read := New()
+	read.Append("Overwritten")
+	_, err = read.Get(0) // builds the offset index before the read replaces the list
+	assert.Nil(t, err, "Unexpected Error")
+
+	err = read.Read(buf)
+	assert.Nil(t, err, "Unexpected Read Error")
+	value, err := read.Get(2)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, value, "Value 2")
+
+	read.Append("Value 4")
+	value, err = read.Get(4)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, value, "Value 4")
+	assert.Equal(t, len(read.offsets), 4, "Expected the append to extend the offset index")
+}
+
+func TestConcurrentGetAndAppend(t *testing.T) {
+	list := New()
+	list.Append(0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 5000; i++ {
+			list.Append(i / 5)
+		}
+	}()
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				row := uint(rand.Intn(int(list.RowCount())))
+				value, err := list.Get(row)
+				if err != nil || value.(int) != int(row/5) {
+					t.Error("Unexpected value for row", row, value, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGetWhileIteratingAfterInsert(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "a", "b", "c")
+	err := list.InsertAt(1, "x")
+	assert.Nil(t, err, "Unexpected Error")
+
+	// Get rebuilds the stale offset index under the read lock held by All, so it must not block
+	done := make(chan []interface{})
+	go func() {
+		result := []interface{}{}
+		for row := range list.All() {
+			value, err := list.Get(row)
+			assert.Nil(t, err, "Unexpected Error")
+			result = append(result, value)
+		}
+		done <- result
+	}()
+	select {
+	case result := <-done:
+		assert.Equal(t, result, []interface{}{"a", "x", "a", "b", "c"})
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get deadlocked inside All")
+	}
+
+	// concurrent readers rebuilding the index after an edit
+	err = list.RemoveAt(0)
+	assert.Nil(t, err, "Unexpected Error")
+	var wg sync.WaitGroup
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list.Iterate(func(index uint, value interface{}) {
+				got, err := list.Get(index)
+				if err != nil || got != value {
+					t.Error("Unexpected value for row", index, got, err)
+				}
+			})
+		}()
+	}
+	wg.Wait()
+}
+
+// collects the values stored in the list in row order
+func listValues(list *RleList) []interface{} {
+	result := []interface{}{}
+	list.Iterate(func(index uint, value interface{}) {
+		result = append(result, value)
+	})
+	return result
+}
+
+func TestInsertAt(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "a", "b", "b")
+
+	assert.Nil(t, list.InsertAt(1, "a"), "Unexpected Error") // extends the block holding the row
+	assert.Equal(t, listValues(list), []interface{}{"a", "a", "a", "b", "b"})
+	assert.Equal(t, list.blockCount, uint(2))
+
+	assert.Nil(t, list.InsertAt(4, "c"), "Unexpected Error") // splits the block
+	assert.Equal(t, listValues(list), []interface{}{"a", "a", "a", "b", "c", "b"})
+	assert.Equal(t, list.blockCount, uint(4))
+
+	assert.Nil(t, list.InsertAt(3, "a"), "Unexpected Error") // merges with the previous block
+	assert.Equal(t, listValues(list), []interface{}{"a", "a", "a", "a", "b", "c", "b"})
+	assert.Equal(t, list.blockCount, uint(4))
+
+	assert.Nil(t, list.InsertAt(0, "z"), "Unexpected Error")
+	assert.Nil(t, list.InsertAt(8, "b"), "Unexpected Error") // appends
+	assert.Equal(t, listValues(list), []interface{}{"z", "a", "a", "a", "a", "b", "c", "b", "b"})
+	checkBlocks(t, list)
+
+	assert.Equal(t, list.InsertAt(10, "x"), ErrRowOutOfRange)
+	value, err := list.Get(6)
+	assert.Nil(t, err, "Unexpected Error")
+	assert.Equal(t, value, "c", "Expected Get to see the inserted rows")
+}
+
+func TestRemoveRange(t *testing.T) {
+	list := New()
+	list.AppendMany("a", "a", "b", "c", "c", "a", "a")
+
+	assert.Nil(t, list.RemoveAt(1), "Unexpected Error") // shortens the block
+	assert.Equal(t, listValues(list), []interface{}{"a", "b", "c", "c", "a", "a"})
+
+	assert.Nil(t, list.RemoveRange(1, 3), "Unexpected Error") // removes whole blocks and merges the neighbours
+	assert.Equal(t, listValues(list), []interface{}{"a", "a", "a"})
+	assert.Equal(t, list.blockCount, uint(1))
+	checkBlocks(t, list)
+
+	assert.Nil(t, list.RemoveRange(3, 0), "Unexpected Error")
+	assert.Equal(t, list.RemoveRange(2, 2), ErrRowOutOfRange)
+	assert.Equal(t, list.RemoveAt(3), ErrRowOutOfRange)
+
+	assert.Nil(t, list.RemoveRange(0, 3), "Unexpected Error")
+	assert.Equal(t, list.rowCount, uint(0))
+	checkBlocks(t, list)
+	assert.Equal(t, list.Append("b"), uint(0), "Expected an emptied list to be appendable")
+}
+
+func TestRandomEdits(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	list := New()
+	expected := []interface{}{}
+
+	for i := 0; i < 2000; i++ {
+		value := rnd.Intn(3)
+		switch op := rnd.Intn(3); {
+		case op == 0 || len(expected) == 0:
+			row := rnd.Intn(len(expected) + 1)
+			assert.Nil(t, list.InsertAt(uint(row), value), "Unexpected Error")
+			expected = append(expected[:row], append([]interface{}{value}, expected[row:]...)...)
+		case op == 1:
+			list.Append(value)
+			expected = append(expected, value)
+		default:
+			row := rnd.Intn(len(expected))
+			length := rnd.Intn(len(expected)-row) / 4
+			assert.Nil(t, list.RemoveRange(uint(row), uint(length)), "Unexpected Error")
+			expected = append(expected[:row], expected[row+length:]...)
+		}
+
+		if i%100 == 0 {
+			assert.Equal(t, listValues(list), expected)
+			checkBlocks(t, list)
+		}
+	}
+	for row, value := range expected {
+		got, err := list.Get(uint(row))
+		if err != nil || got != value {
+			t.Fatal("Unexpected value for row", row, got, err)
+		}
+	}
+}
+
+func TestColumnConformance(t *testing.T) {
+	columntest.Run(t, func() column.Column { return New() })
+}
+
 const fileReadWriteTestCount int = 1000000
 
 func TestIteratorWriteToFile(t *testing.T) {
@@ -300,3 +736,14 @@ func BenchmarkWriteReadSpeedToBuffer(b *testing.B) {
 	list.Read(buf)
 
 }
+
+func BenchmarkGet(b *testing.B) {
+	list := New()
+	for i := 0; i < 100000; i++ {
+		list.Append(i / 10)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		list.Get(uint(n % 100000))
+	}
+}
@@ -5,16 +5,36 @@ package rlelist
 
 import (
 	"container/list"
+	"context"
 	"encoding/gob"
+	"errors"
 	"io"
+	"iter"
+	"sort"
 	"sync"
+
+	"github.com/lummie/golib/column"
+)
+
+var (
+	ErrRowOutOfRange = errors.New("rlelist: row out of range")
+
+	// errors returned by Read when the stream is not a valid RLELIST
+	ErrNotRleList         = errors.New("rlelist: tried to load a stream that is not RLELIST")
+	ErrInvalidBlockCount  = errors.New("rlelist: block count is not consistent with the row count")
+	ErrEmptyBlock         = errors.New("rlelist: block holds no rows")
+	ErrBlockNotContiguous = errors.New("rlelist: block does not start where the previous block ends")
+	ErrRowCountMismatch   = errors.New("rlelist: block lengths do not sum to the row count")
 )
 
 type RleList struct {
 	sync.RWMutex
-	list       *list.List // linked list storing the rows
-	blockCount uint       // number of blocks added
-	rowCount   uint       // number of rows stored
+	list        *list.List      // linked list storing the rows
+	blockCount  uint            // number of blocks added
+	rowCount    uint            // number of rows stored
+	offsets     []*list.Element // elements of the list in row order for positional lookups, nil when it must be rebuilt
+	offsetsLock sync.Mutex      // held by readers while they rebuild or search the offset index
+	version     uint64          // incremented whenever blocks are edited in place or replaced, so a Cursor can find its row again
 }
 
 type block struct {
@@ -28,54 +48,291 @@ func New() *RleList {
 		list:       list.New(),
 		blockCount: 0,
 		rowCount:   0,
+		offsets:    []*list.Element{},
 	}
 }
 
+// returns the number of rows stored in the RleList
+func (r *RleList) RowCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+	return r.rowCount
+}
+
+// returns the number of run length encoded blocks stored in the RleList
+func (r *RleList) BlockCount() uint {
+	r.RLock()
+	defer r.RUnlock()
+	return r.blockCount
+}
+
 // appends a row to the list
 func (r *RleList) Append(value interface{}) uint {
+	r.Lock()
+	defer r.Unlock()
+	return r.appendRunLocked(value, 1)
+}
+
+// appends each value as a row of the list, taking the lock once for the whole batch
+// returns the row index of the first value appended, or the number of rows if there are no values
+func (r *RleList) AppendMany(values ...interface{}) uint {
+	r.Lock()
+	defer r.Unlock()
+
+	first := r.rowCount
+	for _, value := range values {
+		r.appendRunLocked(value, 1)
+	}
+	return first
+}
+
+// appends count rows all holding value in a single step, extending the last block if it holds the same value
+// returns the row index of the first row appended, or the number of rows if count is 0
+func (r *RleList) AppendRun(value interface{}, count uint) uint {
+	r.Lock()
+	defer r.Unlock()
+
+	first := r.rowCount
+	if count > 0 {
+		r.appendRunLocked(value, count)
+	}
+	return first
+}
+
+// appends count rows holding value, extending the last block if it holds the same value
+// returns the row index of the last row appended, the caller must hold the write lock and count must be at least 1
+func (r *RleList) appendRunLocked(value interface{}, count uint) uint {
 	// check if the list is empty and if so add the new block
 	lastBlockListItem := r.list.Back()
 	if lastBlockListItem == nil {
 		newBlock := &block{
 			RowIndex: 0,
-			Length:   1,
+			Length:   count,
 			Value:    value,
 		}
-		r.Lock()
-		defer r.Unlock()
-		r.list.PushBack(newBlock) // add the new block to the list
-		r.blockCount += 1         // increment the number of blocks stored
-		r.rowCount += 1           // increment the number of rows
-		return newBlock.RowIndex
+		r.pushBackLocked(newBlock) // add the new block to the list
+		r.blockCount += 1          // increment the number of blocks stored
+		r.rowCount += count        // increment the number of rows
+		return newBlock.RowIndex + count - 1
 	}
 
 	// lastBlock is assigned so compare the stored value
 	lastBlock := lastBlockListItem.Value.(*block)
 	if lastBlock.Value == value {
 		// the value in the lastBlock is the same as the value to store so just increment then Length
-		r.Lock()
-		defer r.Unlock()
-		lastBlock.Length += 1
-		r.rowCount += 1 // increment the number of rows
+		lastBlock.Length += count
+		r.rowCount += count // increment the number of rows
 		return lastBlock.RowIndex + lastBlock.Length - 1
 	}
 
 	// the value is the lastBlock is different so we need to add a new block
 	newBlock := &block{
 		RowIndex: lastBlock.RowIndex + lastBlock.Length,
-		Length:   1,
+		Length:   count,
 		Value:    value,
 	}
+	r.pushBackLocked(newBlock) // add the new block to the list
+	r.blockCount += 1          // increment the number of blocks stored
+	r.rowCount += count        // increment the number of rows
+	return newBlock.RowIndex + count - 1
+}
+
+// adds the block to the end of the list, keeping the offset index up to date if it has been built
+// the caller must hold the write lock
+func (r *RleList) pushBackLocked(b *block) {
+	element := r.list.PushBack(b)
+	if r.offsets != nil {
+		r.offsets = append(r.offsets, element)
+	}
+}
+
+// returns the value stored in row, or ErrRowOutOfRange if the row is not stored
+// the block holding the row is found by a binary search of an offset index over the list elements, so lookups are
+// O(log n) in the number of blocks. Appends keep the index up to date, other changes to the list mark it stale and it
+// is rebuilt by the next Get
+func (r *RleList) Get(row uint) (interface{}, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if row >= r.rowCount {
+		return nil, ErrRowOutOfRange
+	}
+	return r.elementLocked(row).Value.(*block).Value, nil
+}
+
+// returns the list element of the block holding row, rebuilding the offset index if it is stale
+// the caller must hold the read or write lock and row must be stored. Readers only hold the read lock, so offsetsLock
+// serialises their rebuilds and searches of the index
+func (r *RleList) elementLocked(row uint) *list.Element {
+	r.offsetsLock.Lock()
+	defer r.offsetsLock.Unlock()
+	if r.offsets == nil {
+		r.rebuildOffsetsLocked()
+	}
+	return r.searchOffsetsLocked(row)
+}
+
+// returns the list element of the block holding row using the offset index, which must be up to date
+func (r *RleList) searchOffsetsLocked(row uint) *list.Element {
+	i := sort.Search(len(r.offsets), func(i int) bool {
+		b := r.offsets[i].Value.(*block)
+		return b.RowIndex+b.Length > row
+	})
+	return r.offsets[i]
+}
+
+// rebuilds the offset index from the list, the caller must hold the write lock or offsetsLock
+func (r *RleList) rebuildOffsetsLocked() {
+	r.offsets = make([]*list.Element, 0, r.list.Len())
+	for listItem := r.list.Front(); listItem != nil; listItem = listItem.Next() {
+		r.offsets = append(r.offsets, listItem)
+	}
+}
+
+// number of rows iterated between checks for cancellation within a long block
+const cancelCheckInterval = 1024
+
+// inserts value as a new row at row, moving the rows from row onwards down by one
+// a row equal to the row count appends the value. The block holding the row is extended if it holds the same value,
+// otherwise it is split around the new row, so adjacent blocks always hold different values
+// returns ErrRowOutOfRange if row is greater than the row count
+func (r *RleList) InsertAt(row uint, value interface{}) error {
 	r.Lock()
 	defer r.Unlock()
-	r.list.PushBack(newBlock) // add the new block to the list
-	r.blockCount += 1         // increment the number of blocks stored
-	r.rowCount += 1           // increment the number of rows
-	return newBlock.RowIndex
+
+	if row > r.rowCount {
+		return ErrRowOutOfRange
+	}
+	if row == r.rowCount {
+		r.appendRunLocked(value, 1)
+		return nil
+	}
+
+	e := r.elementLocked(row)
+	b := e.Value.(*block)
+	start := e // first element whose row index may have changed
+	switch {
+	case b.Value == value:
+		// the block already holds the value so just increment the Length
+		b.Length += 1
+
+	case row == b.RowIndex:
+		// insert a new block in front of the block, which may then merge with the previous block
+		inserted := r.list.InsertBefore(&block{RowIndex: row, Length: 1, Value: value}, e)
+		r.blockCount += 1
+		start = inserted
+		if previous := inserted.Prev(); previous != nil && r.mergeNextLocked(previous) {
+			start = previous
+		}
+
+	default:
+		// split the block around the new row
+		tail := &block{RowIndex: row, Length: b.RowIndex + b.Length - row, Value: b.Value}
+		b.Length = row - b.RowIndex
+		inserted := r.list.InsertAfter(&block{RowIndex: row, Length: 1, Value: value}, e)
+		r.list.InsertAfter(tail, inserted)
+		r.blockCount += 2
+	}
+
+	r.rowCount += 1
+	r.renumberLocked(start)
+	r.offsets = nil
+	r.version++
+	return nil
+}
+
+// removes row, moving the following rows up by one
+// returns ErrRowOutOfRange if the row is not stored
+func (r *RleList) RemoveAt(row uint) error {
+	return r.RemoveRange(row, 1)
 }
 
-// iterator function type delaration
-type IteratorFn func(index uint, value interface{})
+// removes the rows index to index+length-1, moving the following rows up by length
+// blocks left empty are removed and the blocks either side of the range are merged if they hold the same value
+// returns ErrRowOutOfRange if any of the rows are not stored
+func (r *RleList) RemoveRange(index uint, length uint) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if index > r.rowCount || length > r.rowCount-index {
+		return ErrRowOutOfRange
+	}
+	if length == 0 {
+		return nil
+	}
+
+	e := r.elementLocked(index)
+	offset := index - e.Value.(*block).RowIndex
+
+	// the block before the range, which is the first block if the range starts part way through it
+	left := e.Prev()
+	if offset > 0 {
+		left = e
+	}
+
+	for remaining := length; remaining > 0; offset = 0 {
+		b := e.Value.(*block)
+		n := b.Length - offset
+		if n > remaining {
+			n = remaining
+		}
+		b.Length -= n
+		remaining -= n
+
+		next := e.Next()
+		if b.Length == 0 {
+			r.list.Remove(e)
+			r.blockCount -= 1
+		}
+		e = next
+	}
+	r.rowCount -= length
+
+	start := r.list.Front()
+	if left != nil {
+		r.mergeNextLocked(left)
+		start = left
+	}
+	r.renumberLocked(start)
+	r.offsets = nil
+	r.version++
+	return nil
+}
+
+// merges the block following e into e if they hold the same value, returning true if they were merged
+// the caller must hold the write lock
+func (r *RleList) mergeNextLocked(e *list.Element) bool {
+	next := e.Next()
+	if next == nil || e.Value.(*block).Value != next.Value.(*block).Value {
+		return false
+	}
+	e.Value.(*block).Length += next.Value.(*block).Length
+	r.list.Remove(next)
+	r.blockCount -= 1
+	return true
+}
+
+// sets the row index of e and every following block so each starts where the previous block ends
+// the caller must hold the write lock
+func (r *RleList) renumberLocked(e *list.Element) {
+	var rowIndex uint
+	if e != nil {
+		if previous := e.Prev(); previous != nil {
+			b := previous.Value.(*block)
+			rowIndex = b.RowIndex + b.Length
+		}
+	}
+	for ; e != nil; e = e.Next() {
+		b := e.Value.(*block)
+		b.RowIndex = rowIndex
+		rowIndex += b.Length
+	}
+}
+
+// iterator function type delaration, shared with the other column implementations
+type IteratorFn = column.IteratorFn
+
+// run iterator function type declaration, called once per block with the starting row, the number of rows and the value
+type RunIteratorFn = column.RunIteratorFn
 
 // iterates each row in the RleList
 func (r *RleList) Iterate(f IteratorFn) {
@@ -93,6 +350,87 @@ func (r *RleList) Iterate(f IteratorFn) {
 	}
 }
 
+// iterates each row in the RleList from the last row to the first
+func (r *RleList) IterateReverse(f IteratorFn) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for listItem := r.list.Back(); listItem != nil; listItem = listItem.Prev() {
+		block := listItem.Value.(*block)
+		for row := block.Length; row > 0; row-- {
+			f(block.RowIndex+row-1, block.Value)
+		}
+	}
+}
+
+// iterates each block in the RleList
+func (r *RleList) IterateRuns(f RunIteratorFn) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for listItem := r.list.Front(); listItem != nil; listItem = listItem.Next() {
+		block := listItem.Value.(*block)
+		f(block.RowIndex, block.Length, block.Value)
+	}
+}
+
+// iterates each row in the RleList, returning ctx.Err() if the context is cancelled before every row has been visited
+// the context is checked between blocks and every cancelCheckInterval rows within a long block
+func (r *RleList) IterateContext(ctx context.Context, f IteratorFn) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	for listItem := r.list.Front(); listItem != nil; listItem = listItem.Next() {
+		block := listItem.Value.(*block)
+		for row := uint(0); row < block.Length; row++ {
+			if row%cancelCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			f(block.RowIndex+row, block.Value)
+		}
+	}
+	return nil
+}
+
+// Run is a value repeated over a contiguous range of rows
+type Run = column.Run
+
+// returns a sequence of the row index and value of each row in the RleList, for use with range
+// like Iterate the read lock is held while ranging, so the loop body must not modify the RleList
+func (r *RleList) All() iter.Seq2[uint, interface{}] {
+	return func(yield func(uint, interface{}) bool) {
+		r.RLock()
+		defer r.RUnlock()
+
+		for listItem := r.list.Front(); listItem != nil; listItem = listItem.Next() {
+			block := listItem.Value.(*block)
+			for row := uint(0); row < block.Length; row++ {
+				if !yield(block.RowIndex+row, block.Value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// returns a sequence of the runs in the RleList, one per block, for use with range
+// the read lock is held while ranging, so the loop body must not modify the RleList
+func (r *RleList) Runs() iter.Seq[Run] {
+	return func(yield func(Run) bool) {
+		r.RLock()
+		defer r.RUnlock()
+
+		for listItem := r.list.Front(); listItem != nil; listItem = listItem.Next() {
+			block := listItem.Value.(*block)
+			if !yield(Run{Index: block.RowIndex, Length: block.Length, Value: block.Value}) {
+				return
+			}
+		}
+	}
+}
+
 // writes the rleList to a writer
 func (r *RleList) Write(writer io.Writer) error {
 	r.Lock()
@@ -127,16 +465,28 @@ func (r *RleList) Write(writer io.Writer) error {
 }
 
 // reads the RleList from a Reader, overwriting the current contents
+// the stream is validated as it is decoded: it must start with the RLELIST type tag, every row must be covered by exactly
+// one block, in order, and the block count can never exceed the row count, so a corrupt stream can't drive the decoding
+// beyond the data actually present. Returns one of the ErrNotRleList, ErrInvalidBlockCount, ErrEmptyBlock,
+// ErrBlockNotContiguous or ErrRowCountMismatch errors if the stream is not valid
 // if an error occurs the RleList will be initialised to empty
 func (r *RleList) Read(reader io.Reader) error {
+	return r.ReadContext(context.Background(), reader)
+}
+
+// like Read, but returns ctx.Err() if the context is cancelled before every block has been decoded
+// as with any other error the RleList is then initialised to empty
+func (r *RleList) ReadContext(ctx context.Context, reader io.Reader) error {
 	r.Lock()
 	defer r.Unlock()
+	r.version++
 	dec := gob.NewDecoder(reader)
 
 	resetToEmpty := func() {
 		r.rowCount = 0
 		r.blockCount = 0
 		r.list = list.New()
+		r.offsets = nil
 	}
 
 	// check we are decoding the correct type
@@ -146,9 +496,14 @@ func (r *RleList) Read(reader io.Reader) error {
 		defer resetToEmpty()
 		return err
 	}
+	if typeCheck != "RLELIST" {
+		defer resetToEmpty()
+		return ErrNotRleList
+	}
 
-	// create the new list to store decoded blocks
+	// create the new list to store decoded blocks, the offset index is rebuilt by the next Get
 	r.list = list.New()
+	r.offsets = nil
 
 	// decode rowCount
 	err = dec.Decode(&r.rowCount)
@@ -164,15 +519,54 @@ func (r *RleList) Read(reader io.Reader) error {
 		return err
 	}
 
+	// every block holds at least one row
+	if r.blockCount > r.rowCount || (r.blockCount == 0) != (r.rowCount == 0) {
+		defer resetToEmpty()
+		return ErrInvalidBlockCount
+	}
+
+	var rows uint // number of rows covered by the blocks decoded so far
 	for i := uint(0); i < r.blockCount; i++ {
+		err = ctx.Err()
+		if err != nil {
+			defer resetToEmpty()
+			return err
+		}
+
 		newBlock := &block{}
 		err = dec.Decode(&newBlock)
 		if err != nil {
 			defer resetToEmpty()
 			return err
 		}
+
+		// check the block follows on from the previous one without overrunning the row count
+		err = validateBlock(newBlock, rows, r.rowCount)
+		if err != nil {
+			defer resetToEmpty()
+			return err
+		}
+		rows += newBlock.Length
 		r.list.PushBack(newBlock)
 	}
 
+	if rows != r.rowCount {
+		defer resetToEmpty()
+		return ErrRowCountMismatch
+	}
+	return nil
+}
+
+// checks a decoded block starts at row and ends within rowCount rows
+func validateBlock(b *block, row uint, rowCount uint) error {
+	if b.Length == 0 {
+		return ErrEmptyBlock
+	}
+	if b.RowIndex != row {
+		return ErrBlockNotContiguous
+	}
+	if b.Length > rowCount-row {
+		return ErrRowCountMismatch
+	}
 	return nil
 }